@@ -28,7 +28,7 @@ BUILD_INFO="${BUILD_INFO} -X ${imp}.buildArch=$(go env GOARCH)"
 BUILD_INFO="${BUILD_INFO} -X ${imp}.buildTags=${TAGS}"
 build_cmds=${SRC}
 if test 'all' = "${build_cmds}"; then
-	build_cmds='mb mbcfg'
+	build_cmds='mb mbcfg mbauth'
 fi
 for cmd in ${build_cmds}; do
 	dst=${cmd}.bin
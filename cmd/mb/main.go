@@ -8,10 +8,12 @@ import (
 	"os"
 
 	"github.com/munbot/master/cmd"
+	"github.com/munbot/master/internal/api/mbctl"
 	"github.com/munbot/master/mb"
 )
 
 func main() {
 	m := cmd.New("mb", mb.New())
+	m.AddCommand("ctl", mbctl.New())
 	m.Main(os.Args[1:])
 }
@@ -0,0 +1,21 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// mbauth command manages munbot console certificates.
+package main
+
+import (
+	"os"
+
+	"github.com/munbot/master/cmd"
+	"github.com/munbot/master/internal/auth/mbauth"
+)
+
+func main() {
+	m := cmd.New("mbauth", mbauth.New())
+	m.AddCommand("sign", mbauth.NewSign())
+	m.AddCommand("revoke", mbauth.NewRevoke())
+	m.AddCommand("trust", mbauth.NewTrust())
+	m.AddCommand("rotate", mbauth.NewRotate())
+	m.Main(os.Args[1:])
+}
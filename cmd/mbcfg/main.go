@@ -9,6 +9,9 @@ import (
 
 	"github.com/munbot/master/cmd"
 	"github.com/munbot/master/config/mbcfg"
+
+	// runtime config options, so they are validated
+	_ "github.com/munbot/master/config/options"
 )
 
 func main() {
@@ -0,0 +1,65 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package codec implements the config files formats.
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/munbot/master/config/value"
+)
+
+// ErrFormat is returned for files with an unsupported extension.
+var ErrFormat error = errors.New("unsupported config format")
+
+// Codec decodes and encodes config files content.
+type Codec interface {
+	// Decode parses the file content.
+	Decode(b []byte) (value.DB, error)
+	// Encode returns db as file content. If src is not empty, it's the
+	// previous file content and the codec should keep from it as much as it
+	// can (comments, options order...).
+	Encode(db value.DB, src []byte) ([]byte, error)
+}
+
+var codecs map[string]Codec = make(map[string]Codec)
+var exts []string = make([]string, 0)
+var mu *sync.RWMutex = new(sync.RWMutex)
+
+func init() {
+	Register(".json", JSON)
+	Register(".ini", INI)
+}
+
+// Register sets the codec for the files with extension ext (".json" in
+// example). If ext was already registered, its codec is replaced.
+func Register(ext string, c Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, found := codecs[ext]; !found {
+		exts = append(exts, ext)
+	}
+	codecs[ext] = c
+}
+
+// Extensions returns the registered extensions, in registration order.
+func Extensions() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string{}, exts...)
+}
+
+// Lookup returns the codec for filename, based on its extension.
+func Lookup(filename string) (Codec, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	ext := filepath.Ext(filename)
+	if c, found := codecs[ext]; found {
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrFormat, ext)
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/munbot/master/config/value"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestLookup(t *testing.T) {
+	check := require.New(t)
+	check.Equal([]string{".json", ".ini"}, Extensions(), "extensions")
+	c, err := Lookup("etc/config.json")
+	check.NoError(err, "lookup json")
+	check.Equal(JSON, c, "json codec")
+	c, err = Lookup("etc/config.ini")
+	check.NoError(err, "lookup ini")
+	check.Equal(INI, c, "ini codec")
+	_, err = Lookup("etc/config.yml")
+	check.True(errors.Is(err, ErrFormat), "lookup error")
+	check.EqualError(err, `unsupported config format: ".yml"`, "lookup error")
+}
+
+func TestJSON(t *testing.T) {
+	check := require.New(t)
+	db, err := JSON.Decode([]byte(`{"master":{"name":"test"}}`))
+	check.NoError(err, "decode")
+	check.Equal(value.DB{"master": value.Map{"name": "test"}}, db, "decode")
+	_, err = JSON.Decode([]byte(`{`))
+	check.Error(err, "decode error")
+	b, err := JSON.Encode(db, []byte(`{}`))
+	check.NoError(err, "encode")
+	check.Equal("{\n\t\"master\": {\n\t\t\"name\": \"test\"\n\t}\n}\n", string(b), "encode")
+}
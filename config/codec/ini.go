@@ -0,0 +1,270 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/munbot/master/config/value"
+)
+
+// INI is the ini files codec. Each [section] header is followed by its
+// option = value lines, and lines starting with ; or # are comments. Values
+// can be double quoted, to keep leading or trailing spaces in example. Encode
+// edits the previous content in place, so comments, blank lines and options
+// order are kept. New options are added at the end of their section, and new
+// sections at the end of the file, sorted by name. Sections not in the encoded
+// db are removed, along with the comments right above their header.
+var INI Codec = iniCodec{}
+
+type iniCodec struct{}
+
+func (iniCodec) Decode(b []byte) (value.DB, error) {
+	doc, err := parseINI(b)
+	if err != nil {
+		return nil, err
+	}
+	db := value.DB{}
+	for _, s := range doc {
+		if s.name == "" {
+			continue
+		}
+		if _, found := db[s.name]; !found {
+			db[s.name] = value.Map{}
+		}
+		for _, l := range s.lines {
+			if l.key != "" {
+				db[s.name][l.key] = l.value
+			}
+		}
+	}
+	return db, nil
+}
+
+func (iniCodec) Encode(db value.DB, src []byte) ([]byte, error) {
+	doc, err := parseINI(src)
+	if err != nil {
+		return nil, err
+	}
+	eol := "\n"
+	if bytes.Contains(src, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+	// options already in the file
+	have := map[string]map[string]bool{}
+	for _, s := range doc {
+		if have[s.name] == nil {
+			have[s.name] = map[string]bool{}
+		}
+		for _, l := range s.lines {
+			if _, found := db[s.name][l.key]; found && l.key != "" {
+				have[s.name][l.key] = true
+			}
+		}
+	}
+	out := make([]string, 0)
+	seen := map[string]map[string]bool{}
+	// sep is set when a blank line must be added before the next section
+	sep := false
+	for _, s := range doc {
+		if s.name == "" {
+			for _, l := range s.lines {
+				out = append(out, l.text)
+			}
+			continue
+		}
+		if _, found := db[s.name]; !found {
+			n := len(out)
+			out = trimComments(out)
+			sep = sep || len(out) < n
+			continue
+		}
+		if sep && len(out) > 0 {
+			out = append(out, "")
+			sep = false
+		}
+		first := seen[s.name] == nil
+		if first {
+			seen[s.name] = map[string]bool{}
+		}
+		opts := db[s.name]
+		lines := make([]string, 0, len(s.lines))
+		// new options go after the last one, or after the header and the
+		// comments following it
+		last := 0
+		lead := true
+		for _, l := range s.lines {
+			if l.key == "" {
+				lines = append(lines, l.text)
+				if strings.TrimSpace(l.text) == "" {
+					lead = false
+				} else if lead {
+					last = len(lines)
+				}
+				continue
+			}
+			lead = false
+			v, found := opts[l.key]
+			if !found || seen[s.name][l.key] {
+				continue
+			}
+			seen[s.name][l.key] = true
+			if v == l.value {
+				lines = append(lines, l.text)
+			} else {
+				lines = append(lines, l.set(v))
+			}
+			last = len(lines)
+		}
+		if first {
+			add := make([]string, 0)
+			for _, k := range sortedKeys(opts) {
+				if !have[s.name][k] {
+					add = append(add, iniOption(k, opts[k]))
+				}
+			}
+			lines = append(lines[:last], append(add, lines[last:]...)...)
+		}
+		out = append(out, lines...)
+	}
+	names := make([]string, 0, len(db))
+	for n := range db {
+		if _, found := have[n]; !found {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+			out = append(out, "")
+		}
+		out = append(out, "["+n+"]")
+		for _, k := range sortedKeys(db[n]) {
+			out = append(out, iniOption(k, db[n][k]))
+		}
+	}
+	if len(out) == 0 {
+		return []byte{}, nil
+	}
+	return []byte(strings.Join(out, eol) + eol), nil
+}
+
+// trimComments removes the comment lines at the end of out, and the blank
+// lines before them.
+func trimComments(out []string) []string {
+	for len(out) > 0 {
+		t := strings.TrimSpace(out[len(out)-1])
+		if t == "" || (t[0] != ';' && t[0] != '#') {
+			break
+		}
+		out = out[:len(out)-1]
+	}
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+type iniLine struct {
+	text  string
+	key   string
+	value string
+	// eq is the index of the = sign in text
+	eq int
+}
+
+// set returns the line with its value replaced, keeping the key format.
+func (l *iniLine) set(v string) string {
+	rest := l.text[l.eq+1:]
+	sp := rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	if strings.TrimSpace(rest) == "" {
+		// it had no value
+		sp = " "
+	}
+	return strings.TrimRight(l.text[:l.eq+1]+sp+iniQuote(v), " \t")
+}
+
+type iniSection struct {
+	// name is empty for the lines before the first section header
+	name  string
+	lines []*iniLine
+}
+
+func parseINI(b []byte) ([]*iniSection, error) {
+	doc := []*iniSection{{}}
+	text := strings.ReplaceAll(string(b), "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return doc, nil
+	}
+	for i, l := range strings.Split(text, "\n") {
+		n := i + 1
+		cur := doc[len(doc)-1]
+		t := strings.TrimSpace(l)
+		switch {
+		case t == "" || t[0] == ';' || t[0] == '#':
+			cur.lines = append(cur.lines, &iniLine{text: l})
+		case t[0] == '[':
+			name := strings.TrimSpace(strings.TrimSuffix(t[1:], "]"))
+			if !strings.HasSuffix(t, "]") || name == "" {
+				return nil, fmt.Errorf("line %d: invalid section: %s", n, t)
+			}
+			doc = append(doc, &iniSection{name: name, lines: []*iniLine{{text: l}}})
+		default:
+			eq := strings.Index(l, "=")
+			if eq < 0 {
+				return nil, fmt.Errorf("line %d: invalid option: %s", n, t)
+			}
+			key := strings.TrimSpace(l[:eq])
+			if key == "" {
+				return nil, fmt.Errorf("line %d: invalid option: %s", n, t)
+			}
+			if cur.name == "" {
+				return nil, fmt.Errorf("line %d: option outside of a section: %s", n, key)
+			}
+			v, err := iniValue(l[eq+1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v", n, key, err)
+			}
+			cur.lines = append(cur.lines, &iniLine{text: l, key: key, value: v, eq: eq})
+		}
+	}
+	return doc, nil
+}
+
+func iniValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value: %s", s)
+		}
+		return v, nil
+	}
+	return s, nil
+}
+
+func iniQuote(v string) string {
+	if v != strings.TrimSpace(v) || strings.HasPrefix(v, `"`) || strings.ContainsAny(v, "\r\n") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+func iniOption(k, v string) string {
+	return strings.TrimRight(k+" = "+iniQuote(v), " ")
+}
+
+func sortedKeys(m value.Map) []string {
+	l := make([]string, 0, len(m))
+	for k := range m {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package codec
+
+import (
+	"testing"
+
+	"github.com/munbot/master/config/value"
+	"github.com/munbot/master/testing/require"
+)
+
+var testINI = `; munbot config
+
+[master]
+# robot name
+name = test
+
+[console]
+; ssh server
+port=7000
+addr = "  spaces  "
+port = 7001
+
+; api settings
+[api]
+enable = true
+`
+
+func TestINIDecode(t *testing.T) {
+	check := require.New(t)
+	db, err := INI.Decode([]byte(testINI))
+	check.NoError(err, "decode")
+	check.Equal(value.DB{
+		"master":  value.Map{"name": "test"},
+		"console": value.Map{"port": "7001", "addr": "  spaces  "},
+		"api":     value.Map{"enable": "true"},
+	}, db, "decode")
+
+	db, err = INI.Decode([]byte(""))
+	check.NoError(err, "decode empty")
+	check.Equal(value.DB{}, db, "decode empty")
+
+	db, err = INI.Decode([]byte("[test]\r\nopt = val\r\n"))
+	check.NoError(err, "decode crlf")
+	check.Equal(value.DB{"test": value.Map{"opt": "val"}}, db, "decode crlf")
+}
+
+func TestINIDecodeError(t *testing.T) {
+	check := require.New(t)
+	for src, msg := range map[string]string{
+		"opt = val":              "line 1: option outside of a section: opt",
+		"[test\nopt = val":       "line 1: invalid section: [test",
+		"[ ]":                    "line 1: invalid section: [ ]",
+		"[test]\nopt":            "line 2: invalid option: opt",
+		"[test]\n = val":         "line 2: invalid option: = val",
+		"[test]\nopt = \"a\"b\"": `line 2: opt: invalid quoted value: "a"b"`,
+	} {
+		_, err := INI.Decode([]byte(src))
+		check.EqualError(err, msg, "decode %q", src)
+	}
+}
+
+func TestINIEncode(t *testing.T) {
+	check := require.New(t)
+	db := value.DB{
+		"master":  value.Map{"name": "munbot", "new": ""},
+		"console": value.Map{"port": "7001", "addr": "  spaces  "},
+		"auth":    value.Map{"enable": "false", "sessions": "3"},
+	}
+	b, err := INI.Encode(db, []byte(testINI))
+	check.NoError(err, "encode")
+	check.Equal(`; munbot config
+
+[master]
+# robot name
+name = munbot
+new =
+
+[console]
+; ssh server
+port=7001
+addr = "  spaces  "
+
+[auth]
+enable = false
+sessions = 3
+`, string(b), "encode")
+
+	// round trip
+	n, err := INI.Decode(b)
+	check.NoError(err, "decode")
+	check.Equal(db, n, "round trip")
+	b2, err := INI.Encode(n, b)
+	check.NoError(err, "encode again")
+	check.Equal(string(b), string(b2), "encode again")
+}
+
+func TestINIEncodeNew(t *testing.T) {
+	check := require.New(t)
+	db := value.DB{
+		"test": value.Map{"opt": `"quoted"`, "multi": "a\nb"},
+		"a.b":  value.Map{"c": "d"},
+	}
+	b, err := INI.Encode(db, nil)
+	check.NoError(err, "encode")
+	check.Equal("[a.b]\nc = d\n\n[test]\nmulti = \"a\\nb\"\nopt = \"\\\"quoted\\\"\"\n",
+		string(b), "encode")
+	n, err := INI.Decode(b)
+	check.NoError(err, "decode")
+	check.Equal(db, n, "round trip")
+
+	b, err = INI.Encode(value.DB{}, nil)
+	check.NoError(err, "encode empty")
+	check.Equal("", string(b), "encode empty")
+
+	b, err = INI.Encode(value.DB{"test": value.Map{"opt": "new", "add": "1"}},
+		[]byte("[test]\r\n; comment\r\nopt = old\r\n"))
+	check.NoError(err, "encode crlf")
+	check.Equal("[test]\r\n; comment\r\nopt = new\r\nadd = 1\r\n", string(b), "encode crlf")
+
+	b, err = INI.Encode(value.DB{"test": value.Map{"opt": "val"}}, []byte("[test]\n; comment\n\n[other]\n"))
+	check.NoError(err, "encode no options")
+	check.Equal("[test]\n; comment\nopt = val\n", string(b), "encode no options")
+
+	b, err = INI.Encode(value.DB{"a": value.Map{"x": "1"}, "c": value.Map{"z": "3"}},
+		[]byte("; head\n\n[a]\nx = 1\n\n# b section\n[b]\ny = 2\n\n[c]\nz = 3\n"))
+	check.NoError(err, "encode deleted section")
+	check.Equal("; head\n\n[a]\nx = 1\n\n[c]\nz = 3\n", string(b), "encode deleted section")
+
+	b, err = INI.Encode(value.DB{"c": value.Map{"z": "3"}},
+		[]byte("; head\n\n# a section\n[a]\nx = 1\n\n[c]\nz = 3\n"))
+	check.NoError(err, "encode deleted first section")
+	check.Equal("; head\n\n[c]\nz = 3\n", string(b), "encode deleted first section")
+
+	_, err = INI.Encode(db, []byte("[test"))
+	check.EqualError(err, "line 1: invalid section: [test", "encode error")
+}
@@ -0,0 +1,32 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/munbot/master/config/value"
+)
+
+// JSON is the json files codec. Sections and options are encoded sorted by
+// name and, as json has no comments, the previous content is not used.
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(b []byte) (value.DB, error) {
+	db := value.DB{}
+	if err := json.Unmarshal(b, &db); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (jsonCodec) Encode(db value.DB, src []byte) ([]byte, error) {
+	b, err := json.MarshalIndent(db, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
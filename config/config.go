@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/munbot/master/config/codec"
 	"github.com/munbot/master/config/internal/parser"
 	"github.com/munbot/master/config/profile"
 	"github.com/munbot/master/config/value"
@@ -17,7 +18,7 @@ import (
 	"github.com/munbot/master/vfs"
 )
 
-// Defaults contains some default values.
+// Defaults contains the default values of the registered options.
 var Defaults value.DB = value.DB{}
 
 var __handler *parser.Config
@@ -26,25 +27,37 @@ func init() {
 	__handler = parser.New()
 }
 
-type dumpFunc func() ([]byte, error)
+type dumpFunc func(name string) ([]byte, error)
 
 // Config is the main configuration manager.
 type Config struct {
 	h    *parser.Config
 	dump dumpFunc
+	// src has the content of the loaded files, so they can be saved keeping
+	// their comments and options order.
+	src map[string][]byte
+}
+
+func newConfig(h *parser.Config) *Config {
+	c := &Config{h: h, src: make(map[string][]byte)}
+	c.dump = c.encode
+	return c
 }
 
 // New creates a new Config object with the global handler attached to it. So
 // _ALL_ instances will work on the same data.
 func New() *Config {
-	return &Config{h: __handler, dump: __handler.Dump}
+	return newConfig(__handler)
 }
 
 // Copy creates a new Config object with a copy of the data handler, so
 // we can detach from the global parser.
 func (c *Config) Copy() *Config {
-	h := c.h.Copy()
-	return &Config{h: h, dump: h.Dump}
+	n := newConfig(c.h.Copy())
+	for fn, b := range c.src {
+		n.src[fn] = b
+	}
+	return n
 }
 
 // SetDefaults set the values from the Defaults global variable. If a section
@@ -53,7 +66,8 @@ func (c *Config) SetDefaults(v value.DB) {
 	c.h.SetDefaults(v)
 }
 
-// Load reads the configuration files from the provided profile.
+// Load reads the configuration files from the provided profile. Registered
+// options are validated after that, see Validate.
 func (c *Config) Load() error {
 	p := profile.New()
 	for _, fn := range p.ListConfigFiles() {
@@ -61,9 +75,27 @@ func (c *Config) Load() error {
 			return fmt.Errorf("%s: %s", fn, err)
 		}
 	}
+	return c.Validate()
+}
+
+// Reload reads the configuration files again, starting from Defaults values. If
+// there's any error the current data is kept.
+func (c *Config) Reload() error {
+	n := newConfig(parser.New())
+	n.SetDefaults(Defaults)
+	if err := n.Load(); err != nil {
+		return err
+	}
+	c.h.Replace(n.h)
+	c.src = n.src
 	return nil
 }
 
+// Restore sets back the data from a Copy.
+func (c *Config) Restore(src *Config) {
+	c.h.Replace(src.h)
+}
+
 func (c *Config) readFile(name string) error {
 	fh, err := vfs.Open(name)
 	if err != nil {
@@ -75,33 +107,66 @@ func (c *Config) readFile(name string) error {
 		}
 	}
 	defer fh.Close()
-	return c.Read(fh)
+	cd, err := codec.Lookup(name)
+	if err != nil {
+		return err
+	}
+	blob, err := ioutil.ReadAll(fh)
+	if err != nil {
+		return err
+	}
+	db, err := cd.Decode(blob)
+	if err != nil {
+		return err
+	}
+	c.h.Merge(name, db)
+	c.src[name] = blob
+	return nil
 }
 
-// Read reads config content from reader.
+// Read reads json config content from reader. Its values source is "reader".
 func (c *Config) Read(r io.Reader) error {
 	blob, err := ioutil.ReadAll(r)
 	if err != nil {
 		return err
 	}
-	return c.h.Load(blob)
+	return c.h.Load("reader", blob)
 }
 
-// Save writes configuration to the provided profile.
+// Save writes configuration to the provided profile. Only the options read
+// from its config file or set since then are saved. If the file was loaded,
+// its comments and options order are kept (as far as its format allows).
 func (c *Config) Save() error {
 	p := profile.New()
 	fn := p.GetConfigFile()
+	blob, err := c.dump(fn)
+	if err != nil {
+		return err
+	}
 	fh, err := vfs.Create(fn)
 	if err != nil {
 		return err
 	}
 	defer fh.Close()
-	return c.Write(fh)
+	if _, err := fh.Write(blob); err != nil {
+		return err
+	}
+	return nil
+}
+
+// encode returns the named config file content, using the codec for its
+// extension.
+func (c *Config) encode(name string) ([]byte, error) {
+	cd, err := codec.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return cd.Encode(c.h.Sourced(name, parser.SourceSet), c.src[name])
 }
 
-// Write writes config content to writer.
+// Write writes config content to writer, as json.
 func (c *Config) Write(w io.Writer) error {
-	blob, err := c.dump()
+	blob, err := c.h.Dump()
 	if err != nil {
 		return err
 	}
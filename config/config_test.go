@@ -38,6 +38,12 @@ func (s *Suite) TearDownTest() {
 	__handler = parser.New()
 }
 
+func (s *Suite) get(c *Config, section, option string) string {
+	v, err := c.Section(section).Get(option)
+	s.require.NoError(err, "get %s.%s", section, option)
+	return v
+}
+
 func (s *Suite) TestLoad() {
 	fh := s.fs.Add("etc/testing/config.json")
 	fh.WriteString("{}")
@@ -109,6 +115,27 @@ func (s *Suite) TestLoadOverride() {
 	//~ s.Equal("dist", c.Munbot.Master.Name, "master name")
 }
 
+func (s *Suite) TestReload() {
+	fh := s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"master":{"name":"test","old":"yes"}}`)
+	c := New()
+	s.require.NoError(c.Load(), "load error")
+	prev := c.Copy()
+
+	fh = s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"master":{"name":"reload"}}`)
+	s.require.NoError(c.Reload(), "reload error")
+	s.Equal("reload", s.get(c, "master", "name"), "master name")
+	s.False(c.HasOption("master", "old"), "stale option")
+
+	s.fs.Add("etc/testing/config.json")
+	s.require.Error(c.Reload(), "reload json error")
+	s.Equal("reload", s.get(c, "master", "name"), "keep data on error")
+
+	c.Restore(prev)
+	s.Equal("test", s.get(c, "master", "name"), "restored master name")
+}
+
 func (s *Suite) TestSave() {
 	s.fs.Add("test/testing/config.json")
 	c := New()
@@ -124,7 +151,7 @@ func (s *Suite) TestSaveError() {
 	s.require.EqualError(err, "stat test/testing/config.json.mock-notfound: no such file or directory", "save error")
 }
 
-func mockDump() ([]byte, error) {
+func mockDump(name string) ([]byte, error) {
 	return nil, errors.New("mock dump error")
 }
 
@@ -143,3 +170,71 @@ func (s *Suite) TestWriteError() {
 	err := c.Save()
 	s.require.EqualError(err, "mock write error", "write error")
 }
+
+func (s *Suite) TestExplain() {
+	sysfh := s.fs.Add("etc/config.json")
+	sysfh.WriteString(`{"console":{"port":"7000"}}`)
+	fh := s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"console":{"port":"7001"},"test":{"opt":"${console.port}"}}`)
+	c := New()
+	c.SetDefaults(Defaults)
+	s.require.NoError(c.Load(), "load error")
+
+	l := c.Explain("console.port")
+	s.require.Len(l, 1, "explain console.port")
+	s.Equal(&Explanation{
+		Name:   "console.port",
+		Value:  "7001",
+		Source: "etc/testing/config.json",
+		Overrides: []Layer{
+			{Source: "etc/config.json", Value: "7000"},
+			{Source: "default", Value: Defaults["console"]["port"]},
+		},
+	}, l[0], "explain console.port")
+
+	l = c.Explain("test")
+	s.require.Len(l, 1, "explain test")
+	s.Equal(&Explanation{Name: "test.opt", Value: "7001", Source: "etc/testing/config.json"},
+		l[0], "explain test.opt evaluated")
+
+	l = c.Explain("MBCONSOLE_PORT")
+	s.require.Len(l, 1, "explain env")
+	s.Equal("default", l[0].Source, "explain env source")
+
+	l = c.Explain("console.")
+	s.Len(l, 3, "explain console options")
+	s.Len(c.Explain("nothing"), 0, "explain nothing")
+}
+
+func (s *Suite) TestSaveINI() {
+	s.fs = vfs.NewMockFilesystem()
+	vfs.SetFilesystem(s.fs)
+	sysfh := s.fs.Add("etc/config.json")
+	sysfh.WriteString(`{"master":{"name":"sys"},"log":{"mode":"info"}}`)
+	fh := s.fs.Add("etc/testing/config.ini")
+	fh.WriteString("; testing\n[console]\n# ssh port\nport = 7000\nenable = true\n")
+	c := New()
+	s.require.NoError(c.Load(), "load error")
+	s.Equal("7000", s.get(c, "console", "port"), "console port")
+
+	p := NewParser(c)
+	s.require.NoError(p.Update("console.port", "7001"), "update")
+	s.require.NoError(p.Unset("console.enable"), "unset")
+	s.require.NoError(p.Update("master.name", "test"), "update global")
+	blob, err := c.dump("etc/testing/config.ini")
+	s.require.NoError(err, "dump error")
+	s.Equal("; testing\n[console]\n# ssh port\nport = 7001\n\n[master]\nname = test\n",
+		string(blob), "saved ini")
+	s.fs.Add("etc/testing/config.ini")
+	s.require.NoError(c.Save(), "save error")
+}
+
+func (s *Suite) TestLoadFormatError() {
+	s.fs = vfs.NewMockFilesystem()
+	vfs.SetFilesystem(s.fs)
+	fh := s.fs.Add("etc/testing/config.ini")
+	fh.WriteString("opt = val\n")
+	c := New()
+	s.require.EqualError(c.Load(), "etc/testing/config.ini: line 1: option outside of a section: opt",
+		"load error")
+}
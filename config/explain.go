@@ -0,0 +1,92 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/munbot/master/config/internal/parser"
+	"github.com/munbot/master/env"
+)
+
+// Layer is a value set by a source: a config file path, "default" or "set"
+// for config options; an env file path, "default", "os" or "flags" for env
+// settings.
+type Layer struct {
+	Source string
+	Value  string
+}
+
+// Explanation shows the value of a config option or env setting, the source
+// it comes from and the lower precedence values it overrides.
+type Explanation struct {
+	Name      string
+	Value     string
+	Source    string
+	Overrides []Layer
+}
+
+// Explain returns the explanations of the config options and env settings
+// which name matches filter. If filter is not an exact name, it's used as a
+// prefix. An empty filter matches everything. Config options are listed first,
+// sorted by name, and env settings after them.
+func (c *Config) Explain(filter string) []*Explanation {
+	opts := parser.Parse(c.h, "")
+	keys := env.Keys()
+	if _, found := opts[filter]; found {
+		return []*Explanation{c.explainOption(filter)}
+	}
+	for _, k := range keys {
+		if k == filter {
+			return []*Explanation{explainEnv(k)}
+		}
+	}
+	names := make([]string, 0, len(opts))
+	for n := range opts {
+		if strings.HasPrefix(n, filter) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	l := make([]*Explanation, 0)
+	for _, n := range names {
+		l = append(l, c.explainOption(n))
+	}
+	for _, k := range keys {
+		if strings.HasPrefix(k, filter) {
+			l = append(l, explainEnv(k))
+		}
+	}
+	return l
+}
+
+func (c *Config) explainOption(name string) *Explanation {
+	sect, opt := splitOption(name)
+	x := &Explanation{Name: name}
+	for i, v := range c.h.Layers(sect, opt) {
+		if i == 0 {
+			x.Value = v.Value
+			x.Source = v.Source
+			continue
+		}
+		x.Overrides = append(x.Overrides, Layer{Source: v.Source, Value: v.Value})
+	}
+	if v, err := c.h.Get(sect, opt); err == nil {
+		x.Value = v
+	}
+	return x
+}
+
+func explainEnv(key string) *Explanation {
+	x := &Explanation{Name: key, Value: env.Get(key)}
+	for i, v := range env.Explain(key) {
+		if i == 0 {
+			x.Source = v.Source
+			continue
+		}
+		x.Overrides = append(x.Overrides, Layer{Source: v.Source, Value: v.Value})
+	}
+	return x
+}
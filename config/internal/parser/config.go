@@ -5,6 +5,7 @@ package parser
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -12,37 +13,163 @@ import (
 	"github.com/munbot/master/config/value"
 )
 
+// ErrMissing is returned for not found options.
+var ErrMissing error = errors.New("option not found")
+
+// ErrLoop is returned when an option references itself.
+var ErrLoop error = errors.New("reference loop")
+
+// Error is returned when an option can not be evaluated.
+type Error struct {
+	Option string
+	Ref    string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Ref != "" {
+		return fmt.Sprintf("%s: %v: ${%s}", e.Option, e.Err, e.Ref)
+	}
+	return fmt.Sprintf("%s: %v", e.Option, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Options sources, besides the files which are named by their path.
+const (
+	SourceDefault string = "default"
+	SourceSet     string = "set"
+)
+
+// Layer is an option value set by a source.
+type Layer struct {
+	Source string
+	Value  string
+}
+
 type Config struct {
 	db value.DB
+	// layers are the values set for each section.option, lower precedence
+	// first
+	layers map[string][]Layer
 }
 
 func New() *Config {
-	return &Config{db: make(value.DB)}
+	return &Config{db: make(value.DB), layers: make(map[string][]Layer)}
 }
 
 func (c *Config) Copy() *Config {
-	db := value.DB{}
+	n := New()
 	for s, l := range c.db {
-		db[s] = value.Map{}
+		n.db[s] = value.Map{}
 		for k, v := range l {
-			db[s][k] = v
+			n.db[s][k] = v
 		}
 	}
-	return &Config{db: db}
+	for k, l := range c.layers {
+		n.layers[k] = append([]Layer{}, l...)
+	}
+	return n
+}
+
+// Replace sets a copy of src data as the config data.
+func (c *Config) Replace(src *Config) {
+	n := src.Copy()
+	c.db = n.db
+	c.layers = n.layers
 }
 
+// SetDefaults sets a copy of the src sections, replacing existing ones.
 func (c *Config) SetDefaults(src value.DB) {
 	for k, v := range src {
-		c.db[k] = v
+		for opt := range c.db[k] {
+			delete(c.layers, k+"."+opt)
+		}
+		c.db[k] = value.Map{}
+		for opt, val := range v {
+			c.set(SourceDefault, k, opt, val)
+		}
+	}
+}
+
+// set sets the option value and records its source.
+func (c *Config) set(src, sect, opt, val string) {
+	if !c.HasSection(sect) {
+		c.db[sect] = value.Map{}
+	}
+	c.db[sect][opt] = val
+	k := sect + "." + opt
+	c.layers[k] = append(c.layers[k], Layer{Source: src, Value: val})
+}
+
+// unset removes the option and its sources.
+func (c *Config) unset(sect, opt string) {
+	delete(c.db[sect], opt)
+	delete(c.layers, sect+"."+opt)
+}
+
+// Layers returns the values set for the option, the one in use first.
+func (c *Config) Layers(sect, opt string) []Layer {
+	l := c.layers[sect+"."+opt]
+	r := make([]Layer, 0, len(l))
+	for i := len(l) - 1; i >= 0; i-- {
+		r = append(r, l[i])
 	}
+	return r
 }
 
 func (c *Config) Dump() ([]byte, error) {
 	return json.Marshal(c.db)
 }
 
-func (c *Config) Load(b []byte) error {
-	return json.Unmarshal(b, &c.db)
+// Load merges the options from the json blob into current data, recording
+// src as their source.
+func (c *Config) Load(src string, b []byte) error {
+	db := value.DB{}
+	if err := json.Unmarshal(b, &db); err != nil {
+		return err
+	}
+	c.Merge(src, db)
+	return nil
+}
+
+// Merge merges the options from db into current data, recording src as their
+// source.
+func (c *Config) Merge(src string, db value.DB) {
+	for sect, opts := range db {
+		if !c.HasSection(sect) {
+			c.db[sect] = value.Map{}
+		}
+		for opt, val := range opts {
+			c.set(src, sect, opt, val)
+		}
+	}
+}
+
+// Sourced returns a copy of the options which value in use was set by any of
+// the srcs sources.
+func (c *Config) Sourced(srcs ...string) value.DB {
+	db := value.DB{}
+	for sect, opts := range c.db {
+		for opt, val := range opts {
+			l := c.layers[sect+"."+opt]
+			if len(l) == 0 {
+				continue
+			}
+			for _, src := range srcs {
+				if l[len(l)-1].Source == src {
+					if _, found := db[sect]; !found {
+						db[sect] = value.Map{}
+					}
+					db[sect][opt] = val
+					break
+				}
+			}
+		}
+	}
+	return db
 }
 
 func (c *Config) HasOption(section, option string) bool {
@@ -58,41 +185,44 @@ func (c *Config) HasSection(name string) bool {
 	return found
 }
 
-func (c *Config) Get(sect, opt string) string {
-	if sect == "" || !c.HasSection(sect) {
-		return fmt.Sprintf("ECFGMISS:%s.%s", sect, opt)
-	}
-	if opt == "" || !c.HasOption(sect, opt) {
-		return fmt.Sprintf("ECFGMISS:%s.%s", sect, opt)
+// Get returns the evaluated (${var} expanded) value of the option.
+func (c *Config) Get(sect, opt string) (string, error) {
+	if !c.HasOption(sect, opt) {
+		return "", &Error{Option: sect + "." + opt, Err: ErrMissing}
 	}
-	return c.eval(c.db[sect][opt])
-}
-
-func (c *Config) eval(value string) string {
-	return os.Expand(value, c.expand())
+	return c.eval(sect+"."+opt, c.db[sect][opt])
 }
 
-func (c *Config) expand() func(string) string {
-	var loop func(value string) string
-	done := make(map[string]bool)
-	fn := func(option string) string {
-		if done[option] {
-			return fmt.Sprintf("ECFGLOOP:%s", option)
+// eval expands the ${section.option} references of value. The first missing
+// or looping reference is returned as error.
+func (c *Config) eval(option, value string) (string, error) {
+	var err error
+	// options being expanded
+	stack := map[string]bool{option: true}
+	var expand func(string) string
+	expand = func(ref string) string {
+		if err != nil {
+			return ""
 		}
-		done[option] = true
-		sect, opt := c.getSectOpt(option)
-		if sect == "" || !c.HasSection(sect) {
-			return fmt.Sprintf("ECFGMISS:%s", option)
+		if stack[ref] {
+			err = &Error{Option: option, Ref: ref, Err: ErrLoop}
+			return ""
 		}
-		if opt == "" || !c.HasOption(sect, opt) {
-			return fmt.Sprintf("ECFGMISS:%s", option)
+		sect, opt := c.getSectOpt(ref)
+		if !c.HasOption(sect, opt) {
+			err = &Error{Option: option, Ref: ref, Err: ErrMissing}
+			return ""
 		}
-		return loop(c.db[sect][opt])
+		stack[ref] = true
+		v := os.Expand(c.db[sect][opt], expand)
+		delete(stack, ref)
+		return v
 	}
-	loop = func(value string) string {
-		return os.Expand(value, fn)
+	v := os.Expand(value, expand)
+	if err != nil {
+		return "", err
 	}
-	return fn
+	return v, nil
 }
 
 func (c *Config) getSectOpt(option string) (string, string) {
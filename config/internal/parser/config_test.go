@@ -4,6 +4,7 @@
 package parser
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/munbot/master/config/value"
@@ -34,15 +35,23 @@ func (c *testCfg) setDefaults() {
 }
 
 func (c *testCfg) loadCfg(s string) {
-	err := c.test.Load([]byte(s))
+	err := c.test.Load("test", []byte(s))
 	c.require.NoError(err, "load cfg error")
 }
 
 func (c *testCfg) loadTestCfg() {
-	err := c.test.Load(tcfg)
+	err := c.test.Load("test", tcfg)
 	c.require.NoError(err, "load test cfg error")
 }
 
+func get(c *Config, sect, opt string) string {
+	v, err := c.Get(sect, opt)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return v
+}
+
 func TestNew(t *testing.T) {
 	c := newTestCfg(t)
 	c.setDefaults()
@@ -51,8 +60,10 @@ func TestNew(t *testing.T) {
 	c.require.NoError(err, "dump error")
 	c.assert.Equal(blob, []byte(`{"master":{"name":"munbot"}}`), "dump blob")
 
-	c.assert.Equal("munbot", c.test.Get("master", "name"), "master.name value")
-	c.assert.Equal("ECFGMISS:master.missing", c.test.Get("master", "missing"), "get missing value")
+	c.assert.Equal("munbot", get(c.test, "master", "name"), "master.name value")
+	_, err = c.test.Get("master", "missing")
+	c.assert.EqualError(err, "master.missing: option not found", "get missing value")
+	c.assert.True(errors.Is(err, ErrMissing), "get missing error")
 
 	c.loadTestCfg()
 	blob, err = c.test.Dump()
@@ -66,6 +77,8 @@ var evalCfg = `{
 	},
 	"test": {
 		"master_name": "${master.name}",
+		"twice": "${master.name}/${test.master_name}",
+		"missing": "${test.nothing}",
 		"alias": "${test.master_name}",
 		"loop": "${test.loop}",
 		"loop2": "${test.loop}",
@@ -80,16 +93,20 @@ var evalCfg = `{
 func TestEval(t *testing.T) {
 	c := newTestCfg(t)
 	c.loadCfg(evalCfg)
-	c.require.Equal("testing", c.test.Get("master", "name"), "master.name")
-	c.assert.Equal("testing", c.test.Get("test", "master_name"), "test master_name")
-	c.assert.Equal("testing", c.test.Get("test", "alias"), "test alias")
-	c.assert.Equal("ECFGLOOP:test.loop", c.test.Get("test", "loop"), "test loop")
-	c.assert.Equal("ECFGLOOP:test.loop", c.test.Get("test", "loop2"), "test loop2")
-	c.assert.Equal("ECFGLOOP:test.loop4", c.test.Get("test", "loop3"), "test loop3")
-	c.assert.Equal("ECFGLOOP:test.loop3", c.test.Get("test", "loop4"), "test loop4")
-	c.assert.Equal("ECFGLOOP:test.loop6", c.test.Get("test", "loop5"), "test loop5")
-	c.assert.Equal("ECFGLOOP:test.loop7", c.test.Get("test", "loop6"), "test loop6")
-	c.assert.Equal("ECFGLOOP:test.loop5", c.test.Get("test", "loop7"), "test loop7")
+	c.require.Equal("testing", get(c.test, "master", "name"), "master.name")
+	c.assert.Equal("testing", get(c.test, "test", "master_name"), "test master_name")
+	c.assert.Equal("testing", get(c.test, "test", "alias"), "test alias")
+	c.assert.Equal("testing/testing", get(c.test, "test", "twice"), "test twice")
+	c.assert.Equal("error: test.missing: option not found: ${test.nothing}", get(c.test, "test", "missing"), "test missing")
+	c.assert.Equal("error: test.loop: reference loop: ${test.loop}", get(c.test, "test", "loop"), "test loop")
+	c.assert.Equal("error: test.loop2: reference loop: ${test.loop}", get(c.test, "test", "loop2"), "test loop2")
+	c.assert.Equal("error: test.loop3: reference loop: ${test.loop3}", get(c.test, "test", "loop3"), "test loop3")
+	c.assert.Equal("error: test.loop4: reference loop: ${test.loop4}", get(c.test, "test", "loop4"), "test loop4")
+	c.assert.Equal("error: test.loop5: reference loop: ${test.loop5}", get(c.test, "test", "loop5"), "test loop5")
+	c.assert.Equal("error: test.loop6: reference loop: ${test.loop6}", get(c.test, "test", "loop6"), "test loop6")
+	c.assert.Equal("error: test.loop7: reference loop: ${test.loop7}", get(c.test, "test", "loop7"), "test loop7")
+	_, err := c.test.Get("test", "loop")
+	c.assert.True(errors.Is(err, ErrLoop), "loop error")
 }
 
 func TestCopy(t *testing.T) {
@@ -97,11 +114,51 @@ func TestCopy(t *testing.T) {
 	c.setDefaults()
 	ct := c.test
 	ct2 := ct.Copy()
-	c.assert.Equal("munbot", ct.Get("master", "name"), "config val")
-	c.assert.Equal("munbot", ct2.Get("master", "name"), "copy val")
+	c.assert.Equal("munbot", get(ct, "master", "name"), "config val")
+	c.assert.Equal("munbot", get(ct2, "master", "name"), "copy val")
 
 	ct.db["master"]["name"] = "ct"
 	ct2.db["master"]["name"] = "ct2"
-	c.assert.Equal("ct", ct.Get("master", "name"), "config val")
-	c.assert.Equal("ct2", ct2.Get("master", "name"), "copy val")
+	c.assert.Equal("ct", get(ct, "master", "name"), "config val")
+	c.assert.Equal("ct2", get(ct2, "master", "name"), "copy val")
+}
+
+func TestLayers(t *testing.T) {
+	c := newTestCfg(t)
+	c.setDefaults()
+	c.assert.Equal([]Layer{{SourceDefault, "munbot"}}, c.test.Layers("master", "name"), "defaults layers")
+	c.require.NoError(c.test.Load("global", []byte(`{"master":{"name":"global"}}`)), "load global")
+	c.require.NoError(c.test.Load("profile", []byte(`{"master":{"name":"profile","opt":"val"}}`)), "load profile")
+	c.assert.Equal([]Layer{
+		{"profile", "profile"},
+		{"global", "global"},
+		{SourceDefault, "munbot"},
+	}, c.test.Layers("master", "name"), "loaded layers")
+	c.assert.Equal([]Layer{{"profile", "val"}}, c.test.Layers("master", "opt"), "opt layers")
+
+	cp := c.test.Copy()
+	c.require.NoError(Update(c.test, "master.name", "testing"), "update")
+	c.assert.Equal(Layer{SourceSet, "testing"}, c.test.Layers("master", "name")[0], "update layer")
+	c.assert.Len(cp.Layers("master", "name"), 3, "copy layers")
+
+	c.require.NoError(Unset(c.test, "master.opt"), "unset")
+	c.assert.Len(c.test.Layers("master", "opt"), 0, "unset layers")
+
+	c.setDefaults()
+	c.assert.Equal([]Layer{{SourceDefault, "munbot"}}, c.test.Layers("master", "name"), "reset defaults layers")
+}
+
+func TestSourced(t *testing.T) {
+	c := newTestCfg(t)
+	c.setDefaults()
+	c.test.Merge("global", value.DB{"master": value.Map{"name": "global", "opt": "global"}})
+	c.test.Merge("profile", value.DB{"master": value.Map{"name": "profile"}})
+	c.require.NoError(Set(c.test, "test.opt", "val"), "set")
+	c.assert.Equal(value.DB{"master": value.Map{"name": "profile"}},
+		c.test.Sourced("profile"), "profile options")
+	c.assert.Equal(value.DB{
+		"master": value.Map{"name": "profile"},
+		"test":   value.Map{"opt": "val"},
+	}, c.test.Sourced("profile", SourceSet), "profile and set options")
+	c.assert.Equal(value.DB{}, c.test.Sourced(SourceDefault), "default options")
 }
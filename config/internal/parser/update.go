@@ -5,8 +5,6 @@ package parser
 
 import (
 	"fmt"
-
-	"github.com/munbot/master/config/value"
 )
 
 func Update(c *Config, option, newval string) error {
@@ -20,7 +18,7 @@ func Update(c *Config, option, newval string) error {
 	if !c.HasOption(sect, opt) {
 		return fmt.Errorf("update invalid option: %s.%s", sect, opt)
 	}
-	c.db[sect][opt] = newval
+	c.set(SourceSet, sect, opt, newval)
 	return nil
 }
 
@@ -29,12 +27,10 @@ func Set(c *Config, option, val string) error {
 	if opt == "" {
 		return fmt.Errorf("set invalid format: %s %s", option, val)
 	}
-	if !c.HasSection(sect) {
-		c.db[sect] = value.Map{}
-	} else if c.HasOption(sect, opt) {
+	if c.HasOption(sect, opt) {
 		return fmt.Errorf("set option already exists: %s.%s", sect, opt)
 	}
-	c.db[sect][opt] = val
+	c.set(SourceSet, sect, opt, val)
 	return nil
 }
 
@@ -44,7 +40,7 @@ func Unset(c *Config, option string) error {
 		return fmt.Errorf("unset invalid option: %s", option)
 	}
 	if c.HasOption(sect, opt) {
-		delete(c.db[sect], opt)
+		c.unset(sect, opt)
 	}
 	return nil
 }
@@ -10,10 +10,10 @@ import (
 func TestUpdate(t *testing.T) {
 	c := newTestCfg(t)
 	c.setDefaults()
-	c.require.Equal("munbot", c.test.Get("master", "name"), "master name default")
+	c.require.Equal("munbot", get(c.test, "master", "name"), "master name default")
 	err := Update(c.test, "master.name", "testing")
 	c.require.NoError(err, "update master.name error")
-	c.assert.Equal("testing", c.test.Get("master", "name"), "master name update")
+	c.assert.Equal("testing", get(c.test, "master", "name"), "master name update")
 	err = Update(c.test, "nosect.opt", "val")
 	c.assert.EqualError(err, "update invalid section: nosect", "update error")
 	err = Update(c.test, "master.noopt", "val")
@@ -24,10 +24,10 @@ func TestSet(t *testing.T) {
 	c := newTestCfg(t)
 	err := Set(c.test, "test.opt", "testing")
 	c.require.NoError(err, "set error")
-	c.assert.Equal("testing", c.test.Get("test", "opt"), "test opt")
+	c.assert.Equal("testing", get(c.test, "test", "opt"), "test opt")
 	err = Set(c.test, "test.opt", "dup")
 	c.require.Error(err, "set dup error")
-	c.assert.Equal("testing", c.test.Get("test", "opt"), "test opt")
+	c.assert.Equal("testing", get(c.test, "test", "opt"), "test opt")
 }
 
 func TestSetEmptyOption(t *testing.T) {
@@ -40,13 +40,13 @@ func TestUnset(t *testing.T) {
 	c := newTestCfg(t)
 	err := Unset(c.test, "test.opt")
 	c.require.NoError(err, "unset error")
-	c.require.Equal("ECFGMISS:test.opt", c.test.Get("test", "opt"), "test opt")
+	c.require.Equal("error: test.opt: option not found", get(c.test, "test", "opt"), "test opt")
 
 	err = Set(c.test, "test.opt", "testing")
 	c.require.NoError(err, "set error")
-	c.require.Equal("testing", c.test.Get("test", "opt"), "test opt")
+	c.require.Equal("testing", get(c.test, "test", "opt"), "test opt")
 
 	err = Unset(c.test, "test.opt")
 	c.require.NoError(err, "unset error")
-	c.require.Equal("ECFGMISS:test.opt", c.test.Get("test", "opt"), "test opt")
+	c.require.Equal("error: test.opt: option not found", get(c.test, "test", "opt"), "test opt")
 }
@@ -18,12 +18,14 @@ type Flags struct {
 	ListAll bool
 	Set     bool
 	Unset   bool
+	Explain bool
 }
 
 func (f *Flags) set(fs *flag.FlagSet) {
 	fs.BoolVar(&f.ListAll, "a", false, "list all options")
 	fs.BoolVar(&f.Set, "set", false, "set option instead of updating it")
 	fs.BoolVar(&f.Unset, "unset", false, "unset option from configuration file")
+	fs.BoolVar(&f.Explain, "explain", false, "show where options and env settings values come from")
 }
 
 type Cmd struct {
@@ -49,6 +51,16 @@ type Main struct {
 func (m *Main) Run(args []string) int {
 	filter := ""
 	alen := len(args)
+	if m.flags.Explain {
+		if alen > 1 {
+			log.Errorf("invalid arguments: %v", args)
+			return 1
+		}
+		if alen == 1 {
+			filter = args[0]
+		}
+		return m.explain(filter)
+	}
 	if alen == 1 {
 		if m.flags.Unset {
 			return m.edit(args[0], "")
@@ -86,6 +98,27 @@ func (m *Main) list(filter string) int {
 	return 0
 }
 
+func (m *Main) explain(filter string) int {
+	cfg := config.New()
+	cfg.SetDefaults(config.Defaults)
+	if err := cfg.Load(); err != nil {
+		log.Error(err)
+		return 1
+	}
+	l := cfg.Explain(filter)
+	if len(l) == 0 {
+		log.Errorf("%s: not found", filter)
+		return 2
+	}
+	for _, x := range l {
+		fmt.Printf("%s=%s (%s)\n", x.Name, x.Value, x.Source)
+		for _, o := range x.Overrides {
+			fmt.Printf("  overrides %s: %s\n", o.Source, o.Value)
+		}
+	}
+	return 0
+}
+
 func (m *Main) sort(n map[string]string) []string {
 	l := make([]string, 0, len(n))
 	for k := range n {
@@ -114,6 +147,10 @@ func (m *Main) edit(option, newval string) int {
 		log.Error(err)
 		return 7
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Error(err)
+		return 7
+	}
 	if err := cfg.Save(); err != nil {
 		log.Error(err)
 		return 8
@@ -0,0 +1,69 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package options registers the master runtime config options. It's imported
+// by the runtime and by the tools that need to validate config files, without
+// linking the runtime itself.
+package options
+
+import (
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/env"
+)
+
+func init() {
+	config.Register(
+		// log settings are applied by the runtime, see core SInit.Init and reconfigure
+		&config.Option{Name: "log.mode", Default: env.Init["MB_LOG"],
+			Enum: []string{"quiet", "info", "verbose", "debug"},
+			Env:  "MB_LOG", Help: "log messages level"},
+		&config.Option{Name: "log.colors", Default: env.Init["MB_LOG_COLORS"],
+			Enum: []string{"auto", "on", "off"},
+			Env:  "MB_LOG_COLORS", Help: "log messages colors"},
+		&config.Option{Name: "master.name", Default: env.Init["MUNBOT"],
+			Env: "MUNBOT", Help: "master robot name"},
+		&config.Option{Name: "api.enable", Type: config.TypeBool, Default: env.Init["MBAPI"],
+			Env: "MBAPI", Help: "enable api server"},
+		&config.Option{Name: "api.debug", Type: config.TypeBool, Default: env.Init["MBAPI_DEBUG"],
+			Env: "MBAPI_DEBUG", Help: "api server debug"},
+		&config.Option{Name: "api.net", Default: env.Init["MBAPI_NET"],
+			Enum: []string{"tcp", "tcp4", "tcp6", "unix"},
+			Env:  "MBAPI_NET", Help: "api server network"},
+		&config.Option{Name: "api.addr", Default: env.Init["MBAPI_ADDR"],
+			Env: "MBAPI_ADDR", Help: "api server address"},
+		&config.Option{Name: "api.port", Type: config.TypeUint, Default: env.Init["MBAPI_PORT"],
+			Max: config.MaxPort, Env: "MBAPI_PORT", Help: "api server port"},
+		&config.Option{Name: "api.path", Default: env.Init["MBAPI_PATH"],
+			Env: "MBAPI_PATH", Help: "api server path prefix"},
+		&config.Option{Name: "api.tls", Type: config.TypeBool, Default: env.Init["MBAPI_TLS"],
+			Env: "MBAPI_TLS", Help: "enable api server tls"},
+		&config.Option{Name: "api.tls_cert", Default: env.Init["MBAPI_TLS_CERT"],
+			Env: "MBAPI_TLS_CERT", Help: "api server tls certificate file"},
+		&config.Option{Name: "api.tls_key", Default: env.Init["MBAPI_TLS_KEY"],
+			Env: "MBAPI_TLS_KEY", Help: "api server tls key file"},
+		&config.Option{Name: "api.tls_clients", Type: config.TypeBool, Default: env.Init["MBAPI_TLS_CLIENTS"],
+			Env: "MBAPI_TLS_CLIENTS", Help: "api server tls clients authentication"},
+		&config.Option{Name: "api.tls_client_ca", Default: env.Init["MBAPI_TLS_CLIENT_CA"],
+			Env: "MBAPI_TLS_CLIENT_CA", Help: "api server tls clients certificate authority file"},
+		&config.Option{Name: "api.tls_client_roles", Default: env.Init["MBAPI_TLS_CLIENT_ROLES"],
+			Env: "MBAPI_TLS_CLIENT_ROLES", Help: "api server tls clients roles file"},
+		&config.Option{Name: "auth.enable", Type: config.TypeBool, Default: env.Init["MBAUTH"],
+			Env: "MBAUTH", Help: "enable authentication"},
+		&config.Option{Name: "auth.sessions", Type: config.TypeUint, Default: env.Init["MBAUTH_SESSIONS"],
+			Env: "MBAUTH_SESSIONS", Help: "max sessions, 0 means no limit"},
+		&config.Option{Name: "auth.key_sessions", Type: config.TypeUint, Default: env.Init["MBAUTH_KEY_SESSIONS"],
+			Env: "MBAUTH_KEY_SESSIONS", Help: "max sessions per key, 0 means no limit"},
+		&config.Option{Name: "auth.max_tries", Type: config.TypeUint, Default: env.Init["MBAUTH_MAX_TRIES"],
+			Env: "MBAUTH_MAX_TRIES", Help: "max login tries per connection"},
+		&config.Option{Name: "auth.ban_tries", Type: config.TypeUint, Default: env.Init["MBAUTH_BAN_TRIES"],
+			Env: "MBAUTH_BAN_TRIES", Help: "failed logins before an address is banned"},
+		&config.Option{Name: "auth.ban_time", Type: config.TypeDuration, Default: env.Init["MBAUTH_BAN_TIME"],
+			Env: "MBAUTH_BAN_TIME", Help: "banned address time"},
+		&config.Option{Name: "console.enable", Type: config.TypeBool, Default: env.Init["MBCONSOLE"],
+			Env: "MBCONSOLE", Help: "enable ssh console server"},
+		&config.Option{Name: "console.addr", Default: env.Init["MBCONSOLE_ADDR"],
+			Env: "MBCONSOLE_ADDR", Help: "ssh console server address"},
+		&config.Option{Name: "console.port", Type: config.TypeUint, Default: env.Init["MBCONSOLE_PORT"],
+			Max: config.MaxPort, Env: "MBCONSOLE_PORT", Help: "ssh console server port"},
+	)
+}
@@ -0,0 +1,20 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package options
+
+import (
+	"testing"
+
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestRegistered(t *testing.T) {
+	check := require.New(t)
+	for _, name := range []string{"log.mode", "master.name", "api.port", "auth.enable", "console.port"} {
+		o, found := config.Lookup(name)
+		check.True(found, name)
+		check.NotEqual("", o.Env, name)
+	}
+}
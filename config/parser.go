@@ -24,9 +24,14 @@ func (p *Parser) Map(filter string) map[string]string {
 }
 
 // Update updates section.option on the global parser object with the new
-// provided value. If section.option does not exists already, an error is
-// returned.
+// provided value. If section.option does not exists already and it's not a
+// registered option, an error is returned.
 func (p *Parser) Update(option, newval string) error {
+	if _, found := Lookup(option); found {
+		if sect, opt := splitOption(option); !p.cfg.HasOption(sect, opt) {
+			return parser.Set(p.cfg, option, newval)
+		}
+	}
 	return parser.Update(p.cfg, option, newval)
 }
 
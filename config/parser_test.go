@@ -26,12 +26,11 @@ func (s *Suite) TestUpdate() {
 	err = p.Update("noopt", "val")
 	s.Error(err, "update error")
 
-	x := c.Section("test")
-	s.Equal("testing", x.Get("opt"), "testing opt")
+	s.Equal("testing", s.get(c, "test", "opt"), "testing opt")
 
 	err = p.Update("test.opt", "newval")
 	s.require.NoError(err, "update error")
-	s.Equal("newval", x.Get("opt"), "testing opt new val")
+	s.Equal("newval", s.get(c, "test", "opt"), "testing opt new val")
 }
 
 func (s *Suite) TestSet() {
@@ -6,13 +6,16 @@ package profile
 
 import (
 	"path/filepath"
+	"strings"
 
+	"github.com/munbot/master/config/codec"
 	"github.com/munbot/master/env"
 	"github.com/munbot/master/log"
 	"github.com/munbot/master/vfs"
 )
 
-// Profile holds the profile settings.
+// Profile holds the profile settings. ConfigFile is the name of the config
+// files used when none of the supported formats exists.
 type Profile struct {
 	Name       string
 	Home       string
@@ -46,19 +49,41 @@ func (p *Profile) GetPath(name string) string {
 // GetConfigFile returns the absolute filename of the configuration file for the
 // current os user. This is the filename used to save configuration updates.
 func (p *Profile) GetConfigFile() string {
-	return p.GetPath(p.ConfigFile)
+	return p.findConfigFile(filepath.Join(p.Config, p.Name))
 }
 
 // ListConfigFiles returns a list of all the profiled filenames to read the
-// configuration from.
+// configuration from. The format of each file is selected by its extension,
+// see codec.Extensions.
 func (p *Profile) ListConfigFiles() []string {
-	d := filepath.Clean(p.Config)
 	return []string{
-		filepath.Join(d, p.ConfigFile),
+		p.findConfigFile(filepath.Clean(p.Config)),
 		p.GetConfigFile(),
 	}
 }
 
+// findConfigFile returns the first existing config file from dir, trying the
+// codec extensions in order. If none exists, ConfigFile is used.
+func (p *Profile) findConfigFile(dir string) string {
+	found := ""
+	base := strings.TrimSuffix(p.ConfigFile, filepath.Ext(p.ConfigFile))
+	for _, ext := range codec.Extensions() {
+		fn := filepath.Join(dir, base+ext)
+		if !vfs.Exist(fn) {
+			continue
+		}
+		if found == "" {
+			found = fn
+		} else {
+			log.Warnf("Config file %s ignored, using %s", fn, found)
+		}
+	}
+	if found == "" {
+		return filepath.Join(dir, p.ConfigFile)
+	}
+	return found
+}
+
 // GetRundir returns the profile named rundir path.
 func (p *Profile) GetRundir() string {
 	return filepath.Join(p.Run, p.Name)
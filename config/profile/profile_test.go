@@ -8,6 +8,7 @@ import (
 
 	"github.com/munbot/master/env"
 	"github.com/munbot/master/testing/assert"
+	"github.com/munbot/master/testing/mock/vfs"
 )
 
 func TestNewDefaults(t *testing.T) {
@@ -18,3 +19,17 @@ func TestNewDefaults(t *testing.T) {
 	check.Equal(env.Get("MB_CONFIG"), p.Config)
 	check.Equal("config.json", p.ConfigFile)
 }
+
+func TestConfigFiles(t *testing.T) {
+	check := assert.New(t)
+	fs := vfs.NewMockFilesystem()
+	vfs.SetFilesystem(fs)
+	defer vfs.SetDefaultFilesystem()
+	p := New()
+	check.Equal([]string{"etc/config.json", "etc/testing/config.json"}, p.ListConfigFiles(), "defaults")
+	fs.Add("etc/testing/config.ini")
+	check.Equal("etc/testing/config.ini", p.GetConfigFile(), "ini config file")
+	check.Equal([]string{"etc/config.json", "etc/testing/config.ini"}, p.ListConfigFiles(), "ini profile")
+	fs.Add("etc/testing/config.json")
+	check.Equal("etc/testing/config.json", p.GetConfigFile(), "json first")
+}
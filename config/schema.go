@@ -0,0 +1,249 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/munbot/master/config/internal/parser"
+	"github.com/munbot/master/config/value"
+)
+
+// ErrMissing is returned for options not found.
+var ErrMissing error = parser.ErrMissing
+
+// ErrLoop is returned for options referencing themselves.
+var ErrLoop error = parser.ErrLoop
+
+// Type is an option value type.
+type Type int
+
+// Option value types.
+const (
+	TypeString Type = iota
+	TypeBool
+	TypeInt
+	TypeUint
+	TypeDuration
+)
+
+var typeName map[Type]string = map[Type]string{
+	TypeString:   "string",
+	TypeBool:     "bool",
+	TypeInt:      "int",
+	TypeUint:     "uint",
+	TypeDuration: "duration",
+}
+
+func (t Type) String() string {
+	if n, ok := typeName[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("Type(%d)", t)
+}
+
+// MaxPort is the higher valid network port number, to be used as Max value of
+// port options.
+const MaxPort int64 = 65535
+
+// Option describes a config option. Min and Max set the allowed range of Int
+// and Uint values, if any of them is not zero. If Enum is not empty, the value
+// must be one of its items. If Env is not empty, the option value is used for
+// that env setting, see Config.Env.
+type Option struct {
+	Name    string
+	Type    Type
+	Default string
+	Min     int64
+	Max     int64
+	Enum    []string
+	Help    string
+	Env     string
+}
+
+// Check validates the option value.
+func (o *Option) Check(val string) error {
+	if err := o.check(val); err != nil {
+		return &OptionError{Option: o.Name, Err: err}
+	}
+	return nil
+}
+
+func (o *Option) check(val string) error {
+	var n int64
+	switch o.Type {
+	case TypeString:
+	case TypeBool:
+		if _, err := strconv.ParseBool(val); err != nil {
+			return fmt.Errorf("invalid %s value %q", o.Type, val)
+		}
+	case TypeInt:
+		i, err := strconv.ParseInt(val, 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q", o.Type, val)
+		}
+		n = i
+	case TypeUint:
+		// 63 bits, so it fits in n for the range check
+		u, err := strconv.ParseUint(val, 10, 63)
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q", o.Type, val)
+		}
+		n = int64(u)
+	case TypeDuration:
+		if _, err := time.ParseDuration(val); err != nil {
+			return fmt.Errorf("invalid %s value %q", o.Type, val)
+		}
+	default:
+		return fmt.Errorf("invalid type: %s", o.Type)
+	}
+	if (o.Type == TypeInt || o.Type == TypeUint) && (o.Min != 0 || o.Max != 0) {
+		if n < o.Min || n > o.Max {
+			return fmt.Errorf("value %s out of range [%d, %d]", val, o.Min, o.Max)
+		}
+	}
+	if len(o.Enum) > 0 {
+		for _, e := range o.Enum {
+			if val == e {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q, must be one of: %s", val, strings.Join(o.Enum, ", "))
+	}
+	return nil
+}
+
+// OptionError is returned when an option value is not valid.
+type OptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Option, e.Err)
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// Errors is a list of validation errors.
+type Errors []error
+
+func (e Errors) Error() string {
+	l := make([]string, 0, len(e))
+	for _, err := range e {
+		l = append(l, err.Error())
+	}
+	if len(l) == 1 {
+		return l[0]
+	}
+	return fmt.Sprintf("%d errors: %s", len(l), strings.Join(l, "; "))
+}
+
+var schema map[string]*Option = make(map[string]*Option)
+var schemaMu *sync.RWMutex = new(sync.RWMutex)
+
+// Register adds the options to the schema and sets their default values. It
+// panics if an option was already registered or if its default value is not
+// valid, so it should be called at init time.
+func Register(opts ...*Option) {
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	for _, o := range opts {
+		sect, opt := splitOption(o.Name)
+		if sect == "" || opt == "" {
+			panic(fmt.Sprintf("config: invalid option name %q", o.Name))
+		}
+		if _, found := schema[o.Name]; found {
+			panic(fmt.Sprintf("config: option %s already registered", o.Name))
+		}
+		if err := o.Check(o.Default); err != nil {
+			panic(fmt.Sprintf("config: default %v", err))
+		}
+		schema[o.Name] = o
+		if _, found := Defaults[sect]; !found {
+			Defaults[sect] = value.Map{}
+		}
+		Defaults[sect][opt] = o.Default
+	}
+}
+
+// Lookup returns the named option schema.
+func Lookup(name string) (*Option, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	o, found := schema[name]
+	return o, found
+}
+
+// Options returns the registered options, sorted by name.
+func Options() []*Option {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	l := make([]*Option, 0, len(schema))
+	for _, o := range schema {
+		l = append(l, o)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Name < l[j].Name })
+	return l
+}
+
+// Validate checks the value of the registered options. All the errors found
+// are returned as Errors. Options not registered are not checked.
+func (c *Config) Validate() error {
+	errs := Errors{}
+	for _, o := range Options() {
+		sect, opt := splitOption(o.Name)
+		if !c.h.HasOption(sect, opt) {
+			continue
+		}
+		val, err := c.h.Get(sect, opt)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := o.Check(val); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Env returns the values of the registered options with an Env setting, keyed
+// by it. Only the options set from config files (or updated) are included, so
+// the env defaults are used for the other ones. See env.SetConfig.
+func (c *Config) Env() map[string]string {
+	m := make(map[string]string)
+	for _, o := range Options() {
+		if o.Env == "" {
+			continue
+		}
+		sect, opt := splitOption(o.Name)
+		l := c.h.Layers(sect, opt)
+		if len(l) == 0 || l[0].Source == parser.SourceDefault {
+			continue
+		}
+		if val, err := c.h.Get(sect, opt); err == nil {
+			m[o.Env] = val
+		}
+	}
+	return m
+}
+
+func splitOption(name string) (string, string) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", ""
+	}
+	return name[:i], name[i+1:]
+}
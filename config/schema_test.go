@@ -0,0 +1,117 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+// the runtime options are registered by their packages, these are the ones
+// used by the tests
+func init() {
+	Register(
+		&Option{Name: "api.net", Default: "tcp", Enum: []string{"tcp", "tcp4", "tcp6", "unix"},
+			Env: "MBAPI_NET"},
+		&Option{Name: "api.port", Type: TypeUint, Default: "6490", Max: MaxPort,
+			Env: "MBAPI_PORT"},
+		&Option{Name: "console.enable", Type: TypeBool, Default: "true"},
+		&Option{Name: "console.addr", Default: "0.0.0.0"},
+		&Option{Name: "console.port", Type: TypeUint, Default: "6492", Max: MaxPort},
+	)
+}
+
+func TestOptionCheck(t *testing.T) {
+	check := require.New(t)
+	o := &Option{Name: "test.port", Type: TypeUint, Max: 65535}
+	check.NoError(o.Check("0"))
+	check.NoError(o.Check("65535"))
+	check.EqualError(o.Check("abc"), `test.port: invalid uint value "abc"`)
+	check.EqualError(o.Check("-1"), `test.port: invalid uint value "-1"`)
+	check.EqualError(o.Check("65536"), `test.port: value 65536 out of range [0, 65535]`)
+
+	o = &Option{Name: "test.int", Type: TypeInt, Min: -1, Max: 1}
+	check.NoError(o.Check("-1"))
+	check.EqualError(o.Check("2"), `test.int: value 2 out of range [-1, 1]`)
+
+	o = &Option{Name: "test.bool", Type: TypeBool}
+	check.NoError(o.Check("true"))
+	check.EqualError(o.Check("yes"), `test.bool: invalid bool value "yes"`)
+
+	o = &Option{Name: "test.duration", Type: TypeDuration}
+	check.NoError(o.Check("1h"))
+	check.EqualError(o.Check("1"), `test.duration: invalid duration value "1"`)
+
+	o = &Option{Name: "test.net", Enum: []string{"tcp", "unix"}}
+	check.NoError(o.Check("unix"))
+	err := o.Check("udp")
+	check.EqualError(err, `test.net: invalid value "udp", must be one of: tcp, unix`)
+	var oerr *OptionError
+	check.True(errors.As(err, &oerr))
+	check.Equal("test.net", oerr.Option)
+
+	check.Equal("duration", TypeDuration.String())
+	check.Equal("Type(9)", Type(9).String())
+}
+
+func TestRegister(t *testing.T) {
+	check := require.New(t)
+	o, found := Lookup("console.port")
+	check.True(found)
+	check.Equal(TypeUint, o.Type)
+	check.Equal(o.Default, Defaults["console"]["port"])
+
+	check.Panics(func() { Register(&Option{Name: "console.port"}) })
+	check.Panics(func() { Register(&Option{Name: "noname"}) })
+	check.Panics(func() { Register(&Option{Name: "test.bad", Type: TypeBool, Default: "bad"}) })
+	_, found = Lookup("test.bad")
+	check.False(found)
+
+	l := Options()
+	for i := 1; i < len(l); i++ {
+		check.True(l[i-1].Name < l[i].Name)
+	}
+}
+
+func (s *Suite) TestLoadValidate() {
+	fh := s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"console":{"port":"abc"},"api":{"net":"udp","port":"${console.port}"},"test":{"opt":"testing"}}`)
+	c := New()
+	c.SetDefaults(Defaults)
+	err := c.Load()
+	s.EqualError(err, `3 errors: api.net: invalid value "udp", must be one of: tcp, tcp4, tcp6, unix; `+
+		`api.port: invalid uint value "abc"; console.port: invalid uint value "abc"`, "validate error")
+	var errs Errors
+	s.require.True(errors.As(err, &errs), "validate errors")
+	s.Len(errs, 3, "validate errors")
+
+	fh = s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"console":{"port":"${api.port}"},"api":{"net":"tcp","port":"${console.port}"}}`)
+	err = c.Load()
+	s.require.Error(err, "validate loop error")
+	s.True(errors.Is(err.(Errors)[0], ErrLoop), "validate loop error")
+}
+
+func (s *Suite) TestUpdateRegistered() {
+	c := New()
+	p := NewParser(c)
+	s.require.NoError(p.Update("console.port", "abc"), "update registered option")
+	s.EqualError(c.Validate(), `console.port: invalid uint value "abc"`, "validate error")
+	s.require.NoError(p.Update("console.port", "6000"), "update registered option")
+	s.NoError(c.Validate(), "validate")
+	s.Error(p.Update("console.noopt", "val"), "update not registered option")
+}
+
+func (s *Suite) TestEnv() {
+	fh := s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"api":{"port":"7000"},"console":{"port":"7001"}}`)
+	c := New()
+	c.SetDefaults(Defaults)
+	s.require.NoError(c.Load(), "load error")
+	s.Equal(map[string]string{"MBAPI_PORT": "7000"}, c.Env(), "options set from files")
+	s.require.NoError(NewParser(c).Update("api.net", "unix"), "update")
+	s.Equal(map[string]string{"MBAPI_NET": "unix", "MBAPI_PORT": "7000"}, c.Env(), "updated options")
+}
@@ -4,10 +4,11 @@
 package config
 
 import (
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/munbot/master/config/internal/parser"
-	"github.com/munbot/master/log"
 )
 
 // Section is the config section manager.
@@ -27,43 +28,66 @@ func (s *Section) HasOption(name string) bool {
 }
 
 // Get returns the evalualed (${var} expanded) content for the named option.
-func (s *Section) Get(name string) string {
-	if !s.HasOption(name) && s.name != "default" {
+// If the option is not in this section, the "default" section is used.
+func (s *Section) Get(name string) (string, error) {
+	if !s.HasOption(name) && s.name != "default" && s.h.HasOption("default", name) {
 		return s.h.Get("default", name)
 	}
 	return s.h.Get(s.name, name)
 }
 
 // GetBool returns the bool value for the named option.
-// If option does not exists or if there's any parsing error, false will be
-// returned as default value.
-func (s *Section) GetBool(name string) bool {
-	r, err := strconv.ParseBool(s.Get(name))
+func (s *Section) GetBool(name string) (bool, error) {
+	v, err := s.Get(name)
 	if err != nil {
-		log.Errorf("config option %s.%s parse error: %s", s.name, name, err)
-		return false
+		return false, err
 	}
-	return r
+	r, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, s.parseError(name, v, TypeBool)
+	}
+	return r, nil
 }
 
 // GetInt returns the int value for the named option.
-// Default value: 0.
-func (s *Section) GetInt(name string) int {
-	r, err := strconv.Atoi(s.Get(name))
+func (s *Section) GetInt(name string) (int, error) {
+	v, err := s.Get(name)
+	if err != nil {
+		return 0, err
+	}
+	r, err := strconv.Atoi(v)
 	if err != nil {
-		log.Errorf("config option %s.%s parse error: %s", s.name, name, err)
-		return 0
+		return 0, s.parseError(name, v, TypeInt)
 	}
-	return r
+	return r, nil
 }
 
 // GetUint returns the uint value for the named option.
-// Default value: 0.
-func (s *Section) GetUint(name string) uint {
-	r, err := strconv.ParseUint(s.Get(name), 10, 0)
+func (s *Section) GetUint(name string) (uint, error) {
+	v, err := s.Get(name)
+	if err != nil {
+		return 0, err
+	}
+	r, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, s.parseError(name, v, TypeUint)
+	}
+	return uint(r), nil
+}
+
+// GetDuration returns the time duration value for the named option.
+func (s *Section) GetDuration(name string) (time.Duration, error) {
+	v, err := s.Get(name)
 	if err != nil {
-		log.Errorf("config option %s.%s parse error: %s", s.name, name, err)
-		return 0
+		return 0, err
 	}
-	return uint(r)
+	r, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, s.parseError(name, v, TypeDuration)
+	}
+	return r, nil
+}
+
+func (s *Section) parseError(name, val string, t Type) error {
+	return &OptionError{Option: s.name + "." + name, Err: fmt.Errorf("invalid %s value %q", t, val)}
 }
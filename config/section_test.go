@@ -3,6 +3,10 @@
 
 package config
 
+import (
+	"time"
+)
+
 func (s *Suite) TestSection() {
 	c := New()
 	s.require.False(c.HasSection("test"), "section test")
@@ -28,7 +32,9 @@ func (s *Suite) TestSectionOption() {
 	x := c.Section("test")
 	s.require.Equal("test", x.Name(), "section test name")
 	s.require.True(x.HasOption("opt"), "test opt")
-	s.Equal("testing", x.Get("opt"), "test opt value")
+	s.Equal("testing", s.get(c, "test", "opt"), "test opt value")
+	_, err = x.Get("missing")
+	s.EqualError(err, "test.missing: option not found", "test missing option")
 }
 
 func (s *Suite) TestSectionGetBool() {
@@ -39,8 +45,11 @@ func (s *Suite) TestSectionGetBool() {
 	s.require.NoError(err, "load error")
 	x := c.Section("test")
 
-	s.False(x.GetBool("opt"), "test opt bool error")
-	s.True(x.GetBool("opt.bool"), "test opt bool")
+	_, err = x.GetBool("opt")
+	s.EqualError(err, `test.opt: invalid bool value "testing"`, "test opt bool error")
+	v, err := x.GetBool("opt.bool")
+	s.require.NoError(err, "test opt bool")
+	s.True(v, "test opt bool")
 }
 
 func (s *Suite) TestSectionGetInt() {
@@ -51,8 +60,11 @@ func (s *Suite) TestSectionGetInt() {
 	s.require.NoError(err, "load error")
 	x := c.Section("test")
 
-	s.Equal(int(0), x.GetInt("opt"), "test opt int error")
-	s.Equal(int(128), x.GetInt("opt.int"), "test opt int")
+	_, err = x.GetInt("opt")
+	s.EqualError(err, `test.opt: invalid int value "testing"`, "test opt int error")
+	v, err := x.GetInt("opt.int")
+	s.require.NoError(err, "test opt int")
+	s.Equal(int(128), v, "test opt int")
 }
 
 func (s *Suite) TestSectionGetUint() {
@@ -63,6 +75,24 @@ func (s *Suite) TestSectionGetUint() {
 	s.require.NoError(err, "load error")
 	x := c.Section("test")
 
-	s.Equal(uint(0), x.GetUint("opt"), "test opt uint error")
-	s.Equal(uint(128), x.GetUint("opt.uint"), "test opt uint")
+	_, err = x.GetUint("opt")
+	s.EqualError(err, `test.opt: invalid uint value "testing"`, "test opt uint error")
+	v, err := x.GetUint("opt.uint")
+	s.require.NoError(err, "test opt uint")
+	s.Equal(uint(128), v, "test opt uint")
+}
+
+func (s *Suite) TestSectionGetDuration() {
+	fh := s.fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"test":{"opt":"testing", "opt.duration":"5m"}}`)
+	c := New()
+	err := c.Load()
+	s.require.NoError(err, "load error")
+	x := c.Section("test")
+
+	_, err = x.GetDuration("opt")
+	s.EqualError(err, `test.opt: invalid duration value "testing"`, "test opt duration error")
+	v, err := x.GetDuration("opt.duration")
+	s.require.NoError(err, "test opt duration")
+	s.Equal(5*time.Minute, v, "test opt duration")
 }
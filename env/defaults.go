@@ -32,19 +32,33 @@ var Init map[string]string = map[string]string{
 	"MB_LOG_COLORS": "auto",
 	"MB_LOG_DEBUG":  "",
 
+	"MB_STOP_TIMEOUT": "30s",
+
 	// these will be set at init() time based on os user env
 	"MB_HOME":   "",
 	"MB_CONFIG": "",
 	"MB_RUN":    "",
 
-	"MBAPI":       "true",
-	"MBAPI_DEBUG": "false",
-	"MBAPI_NET":   "tcp",
-	"MBAPI_ADDR":  "127.0.0.1",
-	"MBAPI_PORT":  "6490",
-	"MBAPI_PATH":  "/",
-
-	"MBAUTH": "true",
+	"MBAPI":                  "true",
+	"MBAPI_DEBUG":            "false",
+	"MBAPI_NET":              "tcp",
+	"MBAPI_ADDR":             "127.0.0.1",
+	"MBAPI_PORT":             "6490",
+	"MBAPI_PATH":             "/",
+	"MBAPI_TLS":              "false",
+	"MBAPI_TLS_CERT":         "api.crt",
+	"MBAPI_TLS_KEY":          "api.key",
+	"MBAPI_TLS_CLIENTS":      "false",
+	"MBAPI_TLS_CLIENT_CA":    "",
+	"MBAPI_TLS_CLIENT_ROLES": "api_clients",
+	"MBAPI_TOKEN":            "",
+
+	"MBAUTH":              "true",
+	"MBAUTH_SESSIONS":     "0",
+	"MBAUTH_KEY_SESSIONS": "0",
+	"MBAUTH_MAX_TRIES":    "3",
+	"MBAUTH_BAN_TRIES":    "10",
+	"MBAUTH_BAN_TIME":     "5m",
 
 	"MBCONSOLE":      "true",
 	"MBCONSOLE_ADDR": "0.0.0.0",
@@ -53,6 +67,10 @@ var Init map[string]string = map[string]string{
 var defvals map[string]string
 var defrw *sync.RWMutex
 
+// cfgvals are the values from config options, see SetConfig. They are guarded
+// by defrw too.
+var cfgvals map[string]string = map[string]string{}
+
 // UNSET is the string returned for values not found in env nor in Defaults either.
 const UNSET string = "__UNSET__"
 
@@ -66,6 +84,17 @@ func defvalGet(key string) string {
 	return UNSET
 }
 
+// cfgvalGet returns the key value from config options, or its default value.
+func cfgvalGet(key string) string {
+	defrw.RLock()
+	v, ok := cfgvals[key]
+	defrw.RUnlock()
+	if ok {
+		return v
+	}
+	return defvalGet(key)
+}
+
 func defvalSet(key, val string) {
 	defrw.Lock()
 	defer defrw.Unlock()
@@ -122,6 +151,7 @@ func loadEnv() {
 		env := envy.Get("MBENV", MBENV)
 		fn := filepath.Join(cfgdir, fmt.Sprintf("%s.env", env))
 		envy.Load(fn)
+		loadedFile(fn)
 	}
 }
 
@@ -130,5 +160,6 @@ func init() {
 	configDir, configDirErr = os.UserConfigDir()
 	initDefaults()
 	userDefaults()
+	initSources()
 	loadEnv()
 }
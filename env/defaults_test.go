@@ -21,6 +21,8 @@ func TestDefaults(t *testing.T) {
 	check.Equal("auto", env.Init["MB_LOG_COLORS"], "MB_LOG_COLORS")
 	check.Equal("", env.Init["MB_LOG_DEBUG"], "MB_LOG_DEBUG")
 
+	check.Equal("30s", env.Init["MB_STOP_TIMEOUT"], "MB_STOP_TIMEOUT")
+
 	check.Equal("", env.Init["MB_HOME"], "MB_HOME")
 	check.Equal("", env.Init["MB_CONFIG"], "MB_CONFIG")
 	check.Equal("", env.Init["MB_RUN"], "MB_RUN")
@@ -31,8 +33,20 @@ func TestDefaults(t *testing.T) {
 	check.Equal("127.0.0.1", env.Init["MBAPI_ADDR"], "MBAPI_ADDR")
 	check.Equal("6490", env.Init["MBAPI_PORT"], "MBAPI_PORT")
 	check.Equal("/", env.Init["MBAPI_PATH"], "MBAPI_PATH")
+	check.Equal("false", env.Init["MBAPI_TLS"], "MBAPI_TLS")
+	check.Equal("api.crt", env.Init["MBAPI_TLS_CERT"], "MBAPI_TLS_CERT")
+	check.Equal("api.key", env.Init["MBAPI_TLS_KEY"], "MBAPI_TLS_KEY")
+	check.Equal("false", env.Init["MBAPI_TLS_CLIENTS"], "MBAPI_TLS_CLIENTS")
+	check.Equal("", env.Init["MBAPI_TLS_CLIENT_CA"], "MBAPI_TLS_CLIENT_CA")
+	check.Equal("api_clients", env.Init["MBAPI_TLS_CLIENT_ROLES"], "MBAPI_TLS_CLIENT_ROLES")
+	check.Equal("", env.Init["MBAPI_TOKEN"], "MBAPI_TOKEN")
 
 	check.Equal("true", env.Init["MBAUTH"], "MBAUTH")
+	check.Equal("0", env.Init["MBAUTH_SESSIONS"], "MBAUTH_SESSIONS")
+	check.Equal("0", env.Init["MBAUTH_KEY_SESSIONS"], "MBAUTH_KEY_SESSIONS")
+	check.Equal("3", env.Init["MBAUTH_MAX_TRIES"], "MBAUTH_MAX_TRIES")
+	check.Equal("10", env.Init["MBAUTH_BAN_TRIES"], "MBAUTH_BAN_TRIES")
+	check.Equal("5m", env.Init["MBAUTH_BAN_TIME"], "MBAUTH_BAN_TIME")
 
 	check.Equal("true", env.Init["MBCONSOLE"], "MBCONSOLE")
 	check.Equal("0.0.0.0", env.Init["MBCONSOLE_ADDR"], "MBCONSOLE_ADDR")
@@ -18,16 +18,17 @@ package env
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gobuffalo/envy"
 
 	"github.com/munbot/master/log"
 )
 
-// Get key value, using Init copy for its default value. If not present, returns
-// UNSET.
+// Get key value, using the config options values (see SetConfig) or the Init
+// copy for its default value. If not present, returns UNSET.
 func Get(key string) string {
-	return envy.Get(key, defvalGet(key))
+	return envy.Get(key, cfgvalGet(key))
 }
 
 // GetBool returns the bool value for key.
@@ -63,23 +64,52 @@ func GetUint(key string) uint {
 	return uint(r)
 }
 
+// GetDuration returns the time.Duration value for key.
+// If there's a parsing error it will be logged and return default value 0.
+func GetDuration(key string) time.Duration {
+	r, err := time.ParseDuration(Get(key))
+	if err != nil {
+		log.Errorf("env parse duration %s: %s", key, err)
+		return 0
+	}
+	return r
+}
+
 // SetDefault sets a default value. Env is not modified, the option is added to
 // the default settings. If it already exists, its value is updated.
 func SetDefault(key, val string) {
 	defvalSet(key, val)
 }
 
+// SetConfig sets the values from config options, replacing the previous ones.
+// They are used for the keys not set in the env (files, os environ or flags),
+// before the default values.
+func SetConfig(m map[string]string) {
+	defrw.Lock()
+	defer defrw.Unlock()
+	cfgvals = make(map[string]string, len(m))
+	for k, v := range m {
+		cfgvals[k] = v
+	}
+}
+
 // Set sets env key value. But it does not modify os.Environ.
 func Set(key, val string) {
+	keep(key, val)
 	envy.Set(key, val)
 }
 
 // SetInt sets an int value.
 func SetInt(key string, val int) {
-	envy.Set(key, strconv.FormatInt(int64(val), 10))
+	Set(key, strconv.FormatInt(int64(val), 10))
 }
 
 // SetUint sets an uint value.
 func SetUint(key string, val uint) {
-	envy.Set(key, strconv.FormatUint(uint64(val), 10))
+	Set(key, strconv.FormatUint(uint64(val), 10))
+}
+
+// SetDuration sets a time.Duration value.
+func SetDuration(key string, val time.Duration) {
+	Set(key, val.String())
 }
@@ -6,6 +6,7 @@ package env_test
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/munbot/master/env"
 	"github.com/munbot/master/testing/assert"
@@ -34,3 +35,61 @@ func TestEnvFile(t *testing.T) {
 	check.Equal("true", env.Get("MBCONSOLE"), "MBCONSOLE")
 	check.Equal("127.0.0.1", env.Get("MBCONSOLE_ADDR"), "MBCONSOLE_ADDR")
 }
+
+func TestGetDuration(t *testing.T) {
+	check := assert.New(t)
+	env.SetDuration("MBTEST_DURATION", 90*time.Second)
+	check.Equal(90*time.Second, env.GetDuration("MBTEST_DURATION"))
+	env.Set("MBTEST_DURATION", "testing")
+	check.Equal(time.Duration(0), env.GetDuration("MBTEST_DURATION"))
+}
+
+func TestReload(t *testing.T) {
+	check := assert.New(t)
+	snap := env.Snapshot()
+	check.Equal("debug", snap["MB_LOG"])
+	defer env.Restore(snap)
+	env.Set("MBTEST_RELOAD", "testing")
+	env.Restore(map[string]string{"MB_LOG": "quiet"})
+	check.Equal("quiet", env.Get("MB_LOG"))
+	env.Reload()
+	check.Equal("debug", env.Get("MB_LOG"), "MB_LOG from env file")
+	check.Equal("testing", env.Get("MBTEST_RELOAD"), "set values are kept")
+}
+
+func TestExplain(t *testing.T) {
+	check := assert.New(t)
+	fn, err := filepath.Abs(filepath.FromSlash("./test.env"))
+	check.NoError(err)
+	check.Equal([]env.Layer{
+		{Source: fn, Value: "debug"},
+		{Source: env.SourceDefault, Value: "verbose"},
+	}, env.Explain("MB_LOG"))
+	check.Equal([]env.Layer{{Source: env.SourceDefault, Value: "6490"}}, env.Explain("MBAPI_PORT"))
+	check.Len(env.Explain("MBTEST_NOTSET"), 0)
+
+	env.SetDefault("MBTEST_EXPLAIN", "default")
+	env.Set("MBTEST_EXPLAIN", "testing")
+	check.Equal([]env.Layer{
+		{Source: env.SourceFlags, Value: "testing"},
+		{Source: env.SourceDefault, Value: "default"},
+	}, env.Explain("MBTEST_EXPLAIN"))
+
+	keys := env.Keys()
+	check.Contains(keys, "MBCONSOLE_PORT")
+	check.Equal("MBAPI", keys[0])
+}
+
+func TestSetConfig(t *testing.T) {
+	check := assert.New(t)
+	defer env.SetConfig(nil)
+	env.SetConfig(map[string]string{"MBAPI_PORT": "7000", "MB_LOG": "quiet"})
+	check.Equal("7000", env.Get("MBAPI_PORT"), "config over default")
+	check.Equal("debug", env.Get("MB_LOG"), "env file over config")
+	check.Equal([]env.Layer{
+		{Source: env.SourceConfig, Value: "7000"},
+		{Source: env.SourceDefault, Value: "6490"},
+	}, env.Explain("MBAPI_PORT"))
+	env.SetConfig(map[string]string{})
+	check.Equal("6490", env.Get("MBAPI_PORT"), "config values replaced")
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package env
+
+import (
+	"sync"
+
+	"github.com/gobuffalo/envy"
+)
+
+var setmu sync.Mutex
+var setvals map[string]string = map[string]string{}
+
+// keep saves the values set from code (command line flags in example), so they
+// are not overriden by Reload.
+func keep(key, val string) {
+	setmu.Lock()
+	defer setmu.Unlock()
+	setvals[key] = val
+}
+
+// Reload reads the ${MBENV}.env file again. Values set using Set (or any of its
+// typed variants) keep precedence over the ones from the file.
+func Reload() {
+	loadEnv()
+	setmu.Lock()
+	defer setmu.Unlock()
+	for k, v := range setvals {
+		envy.Set(k, v)
+	}
+}
+
+// Snapshot returns the current values of the Init settings.
+func Snapshot() map[string]string {
+	m := make(map[string]string, len(Init))
+	for k := range Init {
+		m[k] = Get(k)
+	}
+	return m
+}
+
+// Restore sets back the values from a Snapshot. Only the changed values are
+// set, so the ones from config options or defaults are still used if they were
+// restored already.
+func Restore(m map[string]string) {
+	for k, v := range m {
+		if Get(k) != v {
+			envy.Set(k, v)
+		}
+	}
+}
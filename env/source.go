@@ -0,0 +1,106 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Settings sources, besides the env files which are named by their path.
+const (
+	SourceDefault string = "default"
+	SourceOS      string = "os"
+	SourceFlags   string = "flags"
+	SourceConfig  string = "config"
+)
+
+// Layer is a setting value from a source.
+type Layer struct {
+	Source string
+	Value  string
+}
+
+type sources struct {
+	mu *sync.Mutex
+	// os environ at init, it includes .env file loaded by envy, so the os
+	// values replaced by it are not known
+	os      map[string]string
+	dotenv  map[string]string
+	dotname string
+	file    map[string]string
+	fname   string
+}
+
+var src *sources = &sources{mu: new(sync.Mutex)}
+
+// initSources saves the os environ, before loading the env file.
+func initSources() {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	src.os = make(map[string]string)
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			src.os[kv[:i]] = kv[i+1:]
+		}
+	}
+	src.dotname, _ = filepath.Abs(".env")
+	src.dotenv, _ = godotenv.Read(src.dotname)
+}
+
+// loadedFile saves the values from the loaded env file.
+func loadedFile(fn string) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	src.fname = fn
+	src.file, _ = godotenv.Read(fn)
+}
+
+// Explain returns the sources which set key value, the one in use first.
+func Explain(key string) []Layer {
+	l := make([]Layer, 0)
+	setmu.Lock()
+	if v, ok := setvals[key]; ok {
+		l = append(l, Layer{SourceFlags, v})
+	}
+	setmu.Unlock()
+	src.mu.Lock()
+	if v, ok := src.file[key]; ok {
+		l = append(l, Layer{src.fname, v})
+	}
+	if v, ok := src.os[key]; ok {
+		if dv, ok := src.dotenv[key]; ok && dv == v {
+			l = append(l, Layer{src.dotname, v})
+		} else {
+			l = append(l, Layer{SourceOS, v})
+		}
+	}
+	src.mu.Unlock()
+	defrw.RLock()
+	if v, ok := cfgvals[key]; ok {
+		l = append(l, Layer{SourceConfig, v})
+	}
+	defrw.RUnlock()
+	if v := defvalGet(key); v != UNSET {
+		l = append(l, Layer{SourceDefault, v})
+	}
+	return l
+}
+
+// Keys returns the settings names with a default value, sorted.
+func Keys() []string {
+	defrw.RLock()
+	defer defrw.RUnlock()
+	l := make([]string, 0, len(defvals))
+	for k := range defvals {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
@@ -6,6 +6,7 @@ require (
 	github.com/gobuffalo/envy v1.9.0
 	github.com/gofrs/uuid v3.3.0+incompatible
 	github.com/gorilla/mux v1.7.4
+	github.com/joho/godotenv v1.3.0
 	github.com/stretchr/testify v1.6.1
 	github.com/subchen/go-trylock/v2 v2.0.0
 	gobot.io/x/gobot v1.14.0
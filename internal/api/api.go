@@ -6,16 +6,21 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"path"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/munbot/master/config/profile"
 	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/internal/metrics"
 	"github.com/munbot/master/log"
 )
 
@@ -30,6 +35,19 @@ func newHTTPServer(h http.Handler) *http.Server {
 	}
 }
 
+// streamWriter prepares w for a long lived response, clearing the server write
+// timeout on the request connection. It returns false if w can't be flushed.
+func streamWriter(w http.ResponseWriter, r *http.Request) (http.Flusher, bool) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+	if c, ok := r.Context().Value(ctxConn).(net.Conn); ok {
+		c.SetWriteDeadline(time.Time{})
+	}
+	return f, true
+}
+
 var _ Server = &Api{}
 
 type Api struct {
@@ -38,24 +56,46 @@ type Api struct {
 	ln     net.Listener
 	enable bool
 	net    string
+	auth   auth.Manager
+	tls    *tls.Config
+	roles  map[string]auth.Role
+	health Health
+	ready  int32
+	lnfile *os.File
 }
 
 func New() Server {
 	a := &Api{mux: mux.NewRouter()}
-	a.server = newHTTPServer(a.mux)
+	a.mux.Path("/healthz").HandlerFunc(a.healthz)
+	a.mux.Path("/readyz").HandlerFunc(a.readyz)
+	a.mux.Path("/metrics").Handler(metrics.Handler())
+	a.server = a.newServer("")
 	return a
 }
 
+func (a *Api) newServer(addr string) *http.Server {
+	s := newHTTPServer(metricsHandler(a.authHandler(a.mux)))
+	s.Addr = addr
+	s.ConnContext = connContext
+	return s
+}
+
 func (a *Api) Configure(c *ServerConfig) error {
 	prof := profile.New()
 	if c.Net == "" {
 		c.Net = env.Get("MBAPI_NET")
 	}
 	if c.Addr == "" {
-		c.Net = env.Get("MBAPI_ADDR")
+		c.Addr = env.Get("MBAPI_ADDR")
 	}
 	a.enable = c.Enable
 	a.net = c.Net
+	a.auth = c.Auth
+	a.health = c.Health
+	a.lnfile = c.ListenFile
+	if a.enable && a.auth == nil {
+		log.Warn("api authentication is disabled!")
+	}
 	if a.net == "tcp" || a.net == "tcp4" || a.net == "tcp6" {
 		a.server.Addr = fmt.Sprintf("%s:%d", c.Addr, c.Port)
 	} else if a.net == "unix" {
@@ -63,18 +103,44 @@ func (a *Api) Configure(c *ServerConfig) error {
 	} else {
 		return fmt.Errorf("api: invalid network %q", a.net)
 	}
+	a.tls = nil
+	if a.enable && c.TLS {
+		if a.net == "unix" {
+			log.Warn("api tls is not used on unix sockets")
+			return nil
+		}
+		return a.setupTLS(c)
+	}
 	return nil
 }
 
 func (a *Api) Start() error {
 	if a.enable {
 		var err error
-		a.ln, err = net.Listen(a.net, a.server.Addr)
+		if a.lnfile != nil {
+			a.ln, err = net.FileListener(a.lnfile)
+			if err == nil {
+				a.server.Addr = a.ln.Addr().String()
+			}
+		} else if a.net == "unix" {
+			a.ln, err = listenUnix(a.server.Addr)
+		} else {
+			a.ln, err = net.Listen(a.net, a.server.Addr)
+		}
 		if err != nil {
 			log.Debugf("listen error: %v", err)
 			return err
 		}
-		log.Printf("Api server http://%s", a.server.Addr)
+		scheme := "http"
+		if a.tls != nil {
+			a.ln = tls.NewListener(a.ln, a.tls)
+			scheme = "https"
+		} else if a.ln.Addr().Network() == "unix" {
+			scheme = "unix"
+		}
+		log.Printf("Api server %s://%s", scheme, a.server.Addr)
+		atomic.StoreInt32(&a.ready, 1)
+		defer atomic.StoreInt32(&a.ready, 0)
 		if err := a.server.Serve(a.ln); err != http.ErrServerClosed {
 			return err
 		}
@@ -84,7 +150,13 @@ func (a *Api) Start() error {
 	return nil
 }
 
+// Ready returns true if the server is accepting requests or if it's disabled.
+func (a *Api) Ready() bool {
+	return !a.enable || atomic.LoadInt32(&a.ready) == 1
+}
+
 func (a *Api) Stop() error {
+	atomic.StoreInt32(&a.ready, 0)
 	if a.enable && a.ln != nil {
 		log.Debugf("server shutdown... timeout in %s", stopTimeout)
 		ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
@@ -95,6 +167,15 @@ func (a *Api) Stop() error {
 				return err
 			}
 		}
+		// a shut down server can not be used again, so get a new one in case
+		// we are restarted
+		addr := a.server.Addr
+		a.server = a.newServer(addr)
+		a.ln = nil
+		// passed sockets are removed by the service manager
+		if a.net == "unix" && a.lnfile == nil {
+			return removeSocket(addr)
+		}
 	} else {
 		log.Debugf("avoid stop... enable:%v ln:%v", a.enable, a.ln == nil)
 	}
@@ -0,0 +1,100 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/internal/console"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/robot/master"
+)
+
+// requiredRole returns the minimum role needed for the request: master commands
+// need the role of the console command that runs them, robots or devices
+// commands need operator access and anything else that does not modify state
+// only needs viewer access.
+func requiredRole(r *http.Request) auth.Role {
+	p := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i, n := range p {
+		if n == "commands" && i < len(p)-1 {
+			for _, x := range p[:i] {
+				if x == "robots" {
+					return auth.Operator
+				}
+			}
+			return console.MasterRole(p[i+1])
+		}
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return auth.Viewer
+	}
+	return auth.Admin
+}
+
+// bearerToken returns the token from the Authorization header, if any.
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="munbot"`)
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(master.Error{Msg: fmt.Sprintf(format, args...)}); err != nil {
+		log.Errorf("api write error: %v", err)
+	}
+}
+
+// authHandler checks requests role before passing them to the handler. The role
+// is taken from the unix socket peer credentials or the client certificate, if
+// verified and mapped to a role, or from the bearer token otherwise. If there's
+// no auth manager requests are not checked. Health checks are always allowed.
+func (a *Api) authHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.auth == nil || r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		role, ok := peerRole(r)
+		if !ok {
+			role, ok = a.certRole(r)
+		}
+		if !ok {
+			token := bearerToken(r)
+			if token == "" {
+				writeError(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+			info, err := a.auth.CheckToken(token)
+			if err != nil {
+				log.Debugf("api auth %s: %v", r.RemoteAddr, err)
+				writeError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+			role, err = auth.ParseRole(info.Role)
+			if err != nil {
+				log.Errorf("api auth token %s: %v", info.ID, err)
+				writeError(w, http.StatusForbidden, "invalid token role")
+				return
+			}
+		}
+		req := requiredRole(r)
+		if !role.Allows(req) {
+			writeError(w, http.StatusForbidden, "%s role required", req)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/testing/assert"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestRequiredRole(t *testing.T) {
+	check := assert.New(t)
+	for _, tc := range []struct {
+		method string
+		path   string
+		role   auth.Role
+	}{
+		{"GET", "/", auth.Viewer},
+		{"GET", "/api/robots", auth.Viewer},
+		{"GET", "/api/commands", auth.Viewer},
+		{"GET", "/api/commands/status", auth.Viewer},
+		{"POST", "/api/commands/exit", auth.Admin},
+		{"POST", "/api/commands/reload", auth.Admin},
+		{"GET", "/api/robots/r0/commands", auth.Viewer},
+		{"POST", "/api/robots/r0/commands/c0", auth.Operator},
+		{"GET", "/api/robots/r0/devices/d0/commands/c0", auth.Operator},
+		{"POST", "/api/robots", auth.Admin},
+		{"GET", "/munbot/v1/config", auth.Viewer},
+		{"POST", "/munbot/v1/stop", auth.Admin},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		check.Equal(tc.role, requiredRole(r), tc.method+" "+tc.path)
+	}
+}
+
+func TestAuthHandler(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_api_auth_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	am := auth.New()
+	check.NoError(am.Configure(dir))
+	_, viewer, err := am.IssueToken("viewer", auth.Viewer, "")
+	check.NoError(err)
+	_, admin, err := am.IssueToken("admin", auth.Admin, "")
+	check.NoError(err)
+
+	a := &Api{auth: am}
+	h := a.authHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do("GET", "/api/robots", "")
+	check.Equal(http.StatusUnauthorized, w.Code)
+	check.Equal(`Bearer realm="munbot"`, w.Header().Get("WWW-Authenticate"))
+	e := master.Error{}
+	check.NoError(json.Unmarshal(w.Body.Bytes(), &e))
+	check.Equal("missing bearer token", e.Msg)
+
+	w = do("GET", "/api/robots", "testing.invalid")
+	check.Equal(http.StatusUnauthorized, w.Code)
+
+	w = do("GET", "/api/robots", viewer)
+	check.Equal(http.StatusOK, w.Code)
+
+	w = do("GET", "/api/commands/status", viewer)
+	check.Equal(http.StatusOK, w.Code)
+
+	w = do("POST", "/api/commands/exit", viewer)
+	check.Equal(http.StatusForbidden, w.Code)
+	check.NoError(json.Unmarshal(w.Body.Bytes(), &e))
+	check.Equal("admin role required", e.Msg)
+
+	w = do("POST", "/api/commands/exit", admin)
+	check.Equal(http.StatusOK, w.Code)
+
+	w = do("GET", "/healthz", "")
+	check.Equal(http.StatusOK, w.Code)
+	w = do("GET", "/readyz", "")
+	check.Equal(http.StatusOK, w.Code)
+	w = do("GET", "/metrics", "")
+	check.Equal(http.StatusUnauthorized, w.Code)
+}
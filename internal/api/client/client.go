@@ -1,11 +1,21 @@
 // Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
 // See LICENSE file.
 
-// Package client defines api's client interfaces.
+// Package client implements api's client.
 package client
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/robot/master"
 )
 
 // based on https://golangbyexample.com/command-design-pattern-in-golang/
@@ -16,9 +26,137 @@ type Receiver interface {
 }
 
 type Commander interface {
-	Exec(*Request) Response
+	Exec(Request) Response
 }
 
 type Invoker interface {
 	Eval(string) error
 }
+
+var _ Commander = &Client{}
+var _ Invoker = &Client{}
+
+// Client executes api requests using a Receiver.
+type Client struct {
+	recv   Receiver
+	prefix string
+	out    io.Writer
+}
+
+// New creates a new client using the receiver. Requests paths are relative
+// to the api path prefix.
+func New(r Receiver, prefix string) *Client {
+	if prefix == "" {
+		prefix = "/"
+	}
+	return &Client{recv: r, prefix: prefix, out: os.Stdout}
+}
+
+// SetOutput sets where Eval writes responses text to. It's os.Stdout by
+// default.
+func (c *Client) SetOutput(w io.Writer) {
+	c.out = w
+}
+
+// Exec sends the request and parses its response.
+func (c *Client) Exec(req Request) Response {
+	url := path.Join(c.prefix, req.Path())
+	var resp *http.Response
+	var err error
+	switch req.Method() {
+	case http.MethodGet:
+		resp, err = c.recv.GET(url)
+	case http.MethodPost:
+		var content string
+		content, err = req.Content()
+		if err == nil {
+			resp, err = c.recv.POST(url, content)
+		}
+	default:
+		err = fmt.Errorf("api client: invalid method %s", req.Method())
+	}
+	if err != nil {
+		return &ErrorResponse{err: err}
+	}
+	defer resp.Body.Close()
+	blob, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return &ErrorResponse{err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := new(apiError)
+		if err := json.Unmarshal(blob, e); err != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return &ErrorResponse{err: fmt.Errorf("api: %s", e.Error)}
+	}
+	return req.Parse(blob)
+}
+
+// Eval parses and executes the command line, writing the response text to the
+// client's output.
+func (c *Client) Eval(line string) error {
+	req, err := Parse(line)
+	if err != nil {
+		return err
+	}
+	resp := c.Exec(req)
+	if _, err := io.WriteString(c.out, resp.FormatText()); err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
+// Status returns master's status.
+func (c *Client) Status() (*master.Status, error) {
+	resp := c.Exec(&StatusCmd{})
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return resp.(*StatusResponse).Result, nil
+}
+
+// Exit asks master to stop.
+func (c *Client) Exit() (*master.Status, error) {
+	resp := c.Exec(&ExitCmd{})
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return resp.(*StatusResponse).Result, nil
+}
+
+// Reload asks master to reload its configuration.
+func (c *Client) Reload() (*master.Status, error) {
+	resp := c.Exec(&ReloadCmd{})
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return resp.(*StatusResponse).Result, nil
+}
+
+// Robots returns the list of robots.
+func (c *Client) Robots() ([]*gobot.JSONRobot, error) {
+	resp := c.Exec(&RobotsCmd{})
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return resp.(*RobotsResponse).Robots, nil
+}
+
+// Devices returns the list of devices of the named robot.
+func (c *Client) Devices(robot string) ([]*gobot.JSONDevice, error) {
+	resp := c.Exec(&DevicesCmd{Robot: robot})
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return resp.(*DevicesResponse).Devices, nil
+}
+
+// DeviceCommand runs a robot's device command and returns its result.
+func (c *Client) DeviceCommand(robot, device, command string, args map[string]interface{}) (interface{}, error) {
+	resp := c.Exec(&DeviceCmd{Robot: robot, Device: device, Command: command, Args: args})
+	if err := resp.Err(); err != nil {
+		return nil, err
+	}
+	return resp.(*ResultResponse).Result, nil
+}
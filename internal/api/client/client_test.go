@@ -0,0 +1,153 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/internal/api/wapp"
+	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/testing/require"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	m := master.NewRobot()
+	if err := m.Configure(&master.Config{}, &wapp.Config{Enable: true, Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	d := m.Gobot().Robot("Munbot").Device("munbot").(gobot.Commander)
+	d.AddCommand("echo", func(args map[string]interface{}) interface{} {
+		return args
+	})
+	return httptest.NewServer(m)
+}
+
+func TestClient(t *testing.T) {
+	check := require.New(t)
+	srv := newTestServer(t)
+	defer srv.Close()
+	c := New(NewHTTP(srv.URL), "/")
+
+	st, err := c.Status()
+	check.NoError(err)
+	check.Equal("ok", st.Status)
+	check.Equal("Init", st.State)
+
+	_, err = c.Exit()
+	check.EqualError(err, "nothing to do here")
+
+	_, err = c.Reload()
+	check.EqualError(err, "master: reload not available")
+
+	robots, err := c.Robots()
+	check.NoError(err)
+	check.Len(robots, 1)
+	check.Equal("Munbot", robots[0].Name)
+
+	devs, err := c.Devices("Munbot")
+	check.NoError(err)
+	check.Len(devs, 1)
+	check.Equal("munbot", devs[0].Name)
+	check.Equal([]string{"echo"}, devs[0].Commands)
+
+	_, err = c.Devices("Nobot")
+	check.EqualError(err, "No Robot found with the name Nobot")
+
+	res, err := c.DeviceCommand("Munbot", "munbot", "echo", map[string]interface{}{"n": 1})
+	check.NoError(err)
+	check.Equal(map[string]interface{}{"n": float64(1)}, res)
+}
+
+func TestClientError(t *testing.T) {
+	check := require.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"missing bearer token"}`))
+	}))
+	defer srv.Close()
+	c := New(NewHTTP(srv.URL), "/")
+	_, err := c.Robots()
+	check.EqualError(err, "api: missing bearer token")
+}
+
+func TestParse(t *testing.T) {
+	check := require.New(t)
+	for _, line := range []string{"", "status now", "devices", "cmd r d", "cmd r d c k", "reload now"} {
+		_, err := Parse(line)
+		check.Error(err, line)
+	}
+	check.Contains(ErrUsage.Error(), "\n  reload\n")
+	_, err := Parse("testing")
+	check.EqualError(err, "invalid command: testing")
+
+	req, err := Parse("cmd r0 d0 c0 s=hi n=1 b=true l=[1,2]")
+	check.NoError(err)
+	cmd := req.(*DeviceCmd)
+	check.Equal("/api/robots/r0/devices/d0/commands/c0", cmd.Path())
+	check.Equal(map[string]interface{}{
+		"s": "hi",
+		"n": float64(1),
+		"b": true,
+		"l": []interface{}{float64(1), float64(2)},
+	}, cmd.Args)
+
+	_, err = Parse("devices my robot")
+	check.Error(err)
+	req, err = Parse(`devices "my robot"`)
+	check.NoError(err)
+	check.Equal("/api/robots/my%20robot/devices", req.Path())
+	req, err = Parse(`cmd r0 d0 say msg="hello world"`)
+	check.NoError(err)
+	check.Equal(map[string]interface{}{"msg": "hello world"}, req.(*DeviceCmd).Args)
+	_, err = Parse(`cmd r0 d0 say msg="hello`)
+	check.EqualError(err, "unclosed quote: \"")
+	req, err = Parse("devices r/0")
+	check.NoError(err)
+	check.Equal("/api/robots/r%2F0/devices", req.Path())
+}
+
+func TestEval(t *testing.T) {
+	check := require.New(t)
+	srv := newTestServer(t)
+	defer srv.Close()
+	c := New(NewHTTP(srv.URL), "/")
+	buf := new(bytes.Buffer)
+	c.SetOutput(buf)
+	check.NoError(c.Eval("robots"))
+	check.Equal("Munbot devices:1 connections:1 commands:0\n", buf.String())
+	buf.Reset()
+	check.NoError(c.Eval("cmd Munbot munbot echo msg=hello"))
+	check.Equal("{\n  \"msg\": \"hello\"\n}\n", buf.String())
+	buf.Reset()
+	check.Error(c.Eval("devices Nobot"))
+	check.Equal("ERROR: No Robot found with the name Nobot\n", buf.String())
+}
+
+func TestStatusFormat(t *testing.T) {
+	check := require.New(t)
+	r := &StatusResponse{Result: &master.Status{
+		Born:   "born",
+		Uptime: "1s",
+		State:  "Run",
+		Status: "ok",
+		Services: []*wapp.ServiceInfo{
+			{Name: "api", Status: "running"},
+			{Name: "console", Status: "backing-off", Restarts: 1, Error: "testing"},
+		},
+		History: []*wapp.TransitionInfo{
+			{From: "Init", To: "Run", Time: time.Date(2020, 7, 10, 0, 0, 0, 0, time.UTC),
+				Cause: "configured"},
+		},
+	}}
+	check.Equal("Status: ok\nState:  Run\nBorn:   born\nUptime: 1s\n"+
+		"Service api: running restarts:0\n"+
+		"Service console: backing-off restarts:1 error:testing\n"+
+		"History 2020-07-10T00:00:00Z Init -> Run: configured\n", r.FormatText())
+}
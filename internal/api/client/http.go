@@ -0,0 +1,107 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var _ Receiver = &HTTP{}
+
+var clientTimeout time.Duration = 15 * time.Second
+
+// HTTP implements the Receiver interface using an http client, over tcp or a
+// unix socket.
+type HTTP struct {
+	client *http.Client
+	base   string
+	token  string
+}
+
+// NewHTTP creates a new receiver for the api server at base url, like
+// http://localhost:6492.
+func NewHTTP(base string) *HTTP {
+	return &HTTP{
+		client: &http.Client{Timeout: clientTimeout},
+		base:   strings.TrimRight(base, "/"),
+	}
+}
+
+// NewHTTPS creates a new receiver for the api server at base url, using the
+// given tls settings.
+func NewHTTPS(base string, cfg *tls.Config) *HTTP {
+	h := NewHTTP(base)
+	h.client.Transport = &http.Transport{TLSClientConfig: cfg}
+	return h
+}
+
+// NewUnix creates a new receiver for the api server listening on the named unix
+// socket file.
+func NewUnix(socket string) *HTTP {
+	var d net.Dialer
+	t := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &HTTP{
+		client: &http.Client{Transport: t, Timeout: clientTimeout},
+		base:   "http://unix",
+	}
+}
+
+// SetToken sets the bearer token sent with every request.
+func (h *HTTP) SetToken(token string) {
+	h.token = token
+}
+
+func (h *HTTP) url(p string) string {
+	if strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://") {
+		return p
+	}
+	return h.base + "/" + strings.TrimLeft(p, "/")
+}
+
+func (h *HTTP) do(c *http.Client, req *http.Request) (*http.Response, error) {
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+	return c.Do(req)
+}
+
+// GET sends a GET request to the url, relative to the base url.
+func (h *HTTP) GET(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, h.url(url), nil)
+	if err != nil {
+		return nil, err
+	}
+	return h.do(h.client, req)
+}
+
+// POST sends a POST request to the url, relative to the base url, with the
+// json encoded content.
+func (h *HTTP) POST(url, content string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, h.url(url), strings.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return h.do(h.client, req)
+}
+
+// Stream sends a GET request to the url, relative to the base url, without
+// any timeout so the response body can be read for as long as the server keeps
+// sending it.
+func (h *HTTP) Stream(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, h.url(url), nil)
+	if err != nil {
+		return nil, err
+	}
+	return h.do(&http.Client{Transport: h.client.Transport}, req)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/munbot/master/utils/cmdline"
+)
+
+var ErrUsage error = errors.New(`usage:
+  status
+  exit
+  reload
+  robots
+  devices robot
+  cmd robot device command [key=value...]`)
+
+// Parse parses a one line command string into a request, split as the console
+// does, so arguments can be quoted. Command arguments values are decoded as json
+// if possible, or used as strings otherwise.
+func Parse(line string) (Request, error) {
+	args, err := cmdline.Split(line)
+	if err != nil {
+		return nil, err
+	}
+	return ParseArgs(args)
+}
+
+// ParseArgs is like Parse but with the command line already split.
+func ParseArgs(args []string) (Request, error) {
+	if len(args) == 0 {
+		return nil, ErrUsage
+	}
+	name, args := args[0], args[1:]
+	switch name {
+	case "status":
+		if len(args) == 0 {
+			return &StatusCmd{}, nil
+		}
+	case "exit":
+		if len(args) == 0 {
+			return &ExitCmd{}, nil
+		}
+	case "reload":
+		if len(args) == 0 {
+			return &ReloadCmd{}, nil
+		}
+	case "robots":
+		if len(args) == 0 {
+			return &RobotsCmd{}, nil
+		}
+	case "devices":
+		if len(args) == 1 {
+			return &DevicesCmd{Robot: args[0]}, nil
+		}
+	case "cmd":
+		if len(args) >= 3 {
+			kw, err := cmdline.Params(args[3:])
+			if err != nil {
+				return nil, err
+			}
+			return &DeviceCmd{Robot: args[0], Device: args[1], Command: args[2], Args: kw}, nil
+		}
+	default:
+		return nil, fmt.Errorf("invalid command: %s", name)
+	}
+	return nil, ErrUsage
+}
@@ -3,8 +3,128 @@
 
 package client
 
-// Response defines the api client's response interface.
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// Request defines the api client's request interface.
 type Request interface {
-	Err() error
-	FormatText() string
+	Method() string
+	Path() string
+	Content() (string, error)
+	Parse(blob []byte) Response
+}
+
+func escape(p ...string) string {
+	for i, s := range p {
+		p[i] = url.PathEscape(s)
+	}
+	return path.Join(p...)
+}
+
+// StatusCmd requests master's status.
+type StatusCmd struct{}
+
+func (c *StatusCmd) Method() string           { return http.MethodGet }
+func (c *StatusCmd) Path() string             { return "/api/commands/status" }
+func (c *StatusCmd) Content() (string, error) { return "", nil }
+
+func (c *StatusCmd) Parse(blob []byte) Response {
+	return parse(blob, new(StatusResponse))
+}
+
+// ExitCmd requests master to stop.
+type ExitCmd struct{}
+
+func (c *ExitCmd) Method() string           { return http.MethodPost }
+func (c *ExitCmd) Path() string             { return "/api/commands/exit" }
+func (c *ExitCmd) Content() (string, error) { return "{}", nil }
+
+func (c *ExitCmd) Parse(blob []byte) Response {
+	return parse(blob, new(StatusResponse))
+}
+
+// ReloadCmd requests master to reload its configuration.
+type ReloadCmd struct{}
+
+func (c *ReloadCmd) Method() string           { return http.MethodPost }
+func (c *ReloadCmd) Path() string             { return "/api/commands/reload" }
+func (c *ReloadCmd) Content() (string, error) { return "{}", nil }
+
+func (c *ReloadCmd) Parse(blob []byte) Response {
+	return parse(blob, new(StatusResponse))
+}
+
+// MasterCmd runs a master command with the given arguments.
+type MasterCmd struct {
+	Command string
+	Args    map[string]interface{}
+}
+
+func (c *MasterCmd) Method() string           { return http.MethodPost }
+func (c *MasterCmd) Path() string             { return "/api/commands/" + escape(c.Command) }
+func (c *MasterCmd) Content() (string, error) { return jsonArgs(c.Args) }
+
+func (c *MasterCmd) Parse(blob []byte) Response {
+	return parse(blob, new(ResultResponse))
+}
+
+// RobotsCmd requests the list of robots.
+type RobotsCmd struct{}
+
+func (c *RobotsCmd) Method() string           { return http.MethodGet }
+func (c *RobotsCmd) Path() string             { return "/api/robots" }
+func (c *RobotsCmd) Content() (string, error) { return "", nil }
+
+func (c *RobotsCmd) Parse(blob []byte) Response {
+	return parse(blob, new(RobotsResponse))
+}
+
+// DevicesCmd requests the list of devices of a robot.
+type DevicesCmd struct {
+	Robot string
+}
+
+func (c *DevicesCmd) Method() string           { return http.MethodGet }
+func (c *DevicesCmd) Path() string             { return "/api/robots/" + escape(c.Robot, "devices") }
+func (c *DevicesCmd) Content() (string, error) { return "", nil }
+
+func (c *DevicesCmd) Parse(blob []byte) Response {
+	return parse(blob, new(DevicesResponse))
+}
+
+// DeviceCmd runs a robot's device command with the given arguments.
+type DeviceCmd struct {
+	Robot   string
+	Device  string
+	Command string
+	Args    map[string]interface{}
+}
+
+func (c *DeviceCmd) Method() string { return http.MethodPost }
+
+func (c *DeviceCmd) Path() string {
+	return "/api/robots/" + escape(c.Robot, "devices", c.Device, "commands", c.Command)
+}
+
+func (c *DeviceCmd) Content() (string, error) {
+	return jsonArgs(c.Args)
+}
+
+func (c *DeviceCmd) Parse(blob []byte) Response {
+	return parse(blob, new(ResultResponse))
+}
+
+func jsonArgs(args map[string]interface{}) (string, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	blob, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return string(blob), nil
 }
@@ -3,8 +3,174 @@
 
 package client
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/robot/master"
+)
+
 // Response defines the api client's response interface.
 type Response interface {
 	Err() error
 	FormatText() string
 }
+
+// apiError is the error message sent by the api server.
+type apiError struct {
+	Error string `json:"error,omitempty"`
+}
+
+func (r *apiError) err() error {
+	if r.Error != "" {
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+// parse decodes the json blob into the response.
+func parse(blob []byte, resp Response) Response {
+	if err := json.Unmarshal(blob, resp); err != nil {
+		return &ErrorResponse{err: fmt.Errorf("api client: %v", err)}
+	}
+	return resp
+}
+
+func errorText(err error) string {
+	return "ERROR: " + err.Error() + "\n"
+}
+
+// ErrorResponse is returned when the request failed.
+type ErrorResponse struct {
+	err error
+}
+
+func (r *ErrorResponse) Err() error {
+	return r.err
+}
+
+func (r *ErrorResponse) FormatText() string {
+	return errorText(r.err)
+}
+
+// StatusResponse holds master's status.
+type StatusResponse struct {
+	apiError
+	Result *master.Status `json:"result,omitempty"`
+}
+
+func (r *StatusResponse) Err() error {
+	if err := r.err(); err != nil {
+		return err
+	}
+	if r.Result == nil {
+		return errors.New("api client: no status")
+	}
+	if r.Result.Status == "" && r.Result.Error != "" {
+		return errors.New(r.Result.Error)
+	}
+	return nil
+}
+
+func (r *StatusResponse) FormatText() string {
+	if err := r.Err(); err != nil {
+		return errorText(err)
+	}
+	s := r.Result
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "Status: %s\n", s.Status)
+	fmt.Fprintf(b, "State:  %s\n", s.State)
+	fmt.Fprintf(b, "Born:   %s\n", s.Born)
+	fmt.Fprintf(b, "Uptime: %s\n", s.Uptime)
+	if s.Error != "" {
+		fmt.Fprintf(b, "Error:  %s\n", s.Error)
+	}
+	if s.Die != "" {
+		fmt.Fprintf(b, "Die:    %s\n", s.Die)
+	}
+	for _, svc := range s.Services {
+		fmt.Fprintf(b, "Service %s: %s restarts:%d", svc.Name, svc.Status, svc.Restarts)
+		if svc.Error != "" {
+			fmt.Fprintf(b, " error:%s", svc.Error)
+		}
+		b.WriteString("\n")
+	}
+	for _, t := range s.History {
+		fmt.Fprintf(b, "History %s %s -> %s", t.Time.Format(time.RFC3339), t.From, t.To)
+		if t.Cause != "" {
+			fmt.Fprintf(b, ": %s", t.Cause)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// RobotsResponse holds the list of robots.
+type RobotsResponse struct {
+	apiError
+	Robots []*gobot.JSONRobot `json:"robots"`
+}
+
+func (r *RobotsResponse) Err() error {
+	return r.err()
+}
+
+func (r *RobotsResponse) FormatText() string {
+	if err := r.Err(); err != nil {
+		return errorText(err)
+	}
+	b := new(strings.Builder)
+	for _, robot := range r.Robots {
+		fmt.Fprintf(b, "%s devices:%d connections:%d commands:%d\n", robot.Name,
+			len(robot.Devices), len(robot.Connections), len(robot.Commands))
+	}
+	return b.String()
+}
+
+// DevicesResponse holds the list of devices of a robot.
+type DevicesResponse struct {
+	apiError
+	Devices []*gobot.JSONDevice `json:"devices"`
+}
+
+func (r *DevicesResponse) Err() error {
+	return r.err()
+}
+
+func (r *DevicesResponse) FormatText() string {
+	if err := r.Err(); err != nil {
+		return errorText(err)
+	}
+	b := new(strings.Builder)
+	for _, d := range r.Devices {
+		fmt.Fprintf(b, "%s %s connection:%s commands:%s\n", d.Name, d.Driver,
+			d.Connection, strings.Join(d.Commands, ","))
+	}
+	return b.String()
+}
+
+// ResultResponse holds a command result.
+type ResultResponse struct {
+	apiError
+	Result interface{} `json:"result"`
+}
+
+func (r *ResultResponse) Err() error {
+	return r.err()
+}
+
+func (r *ResultResponse) FormatText() string {
+	if err := r.Err(); err != nil {
+		return errorText(err)
+	}
+	blob, err := json.MarshalIndent(r.Result, "", "  ")
+	if err != nil {
+		return errorText(err)
+	}
+	return string(blob) + "\n"
+}
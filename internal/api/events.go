@@ -0,0 +1,95 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/munbot/master/internal/event"
+)
+
+var heartbeat time.Duration = 15 * time.Second
+
+type eventsHandler struct {
+	hub *event.Hub
+}
+
+// EventsHandler returns a handler streaming robots devices events as
+// server-sent events. The robot, device and event query parameters filter the
+// events to send. Clients can resume the stream using the Last-Event-ID header
+// or the since query parameter.
+func EventsHandler(hub *event.Hub) http.Handler {
+	return &eventsHandler{hub}
+}
+
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.hub == nil {
+		writeError(w, http.StatusServiceUnavailable, "events not available")
+		return
+	}
+	q := r.URL.Query()
+	f := &event.Filter{
+		Robot:  q.Get("robot"),
+		Device: q.Get("device"),
+		Event:  q.Get("event"),
+	}
+	since := r.Header.Get("Last-Event-ID")
+	if since == "" {
+		since = q.Get("since")
+	}
+	var last uint64
+	if since != "" {
+		var err error
+		last, err = strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid event id: %s", since)
+			return
+		}
+	}
+	fl, ok := streamWriter(w, r)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	l, sub := h.hub.Subscribe(f, last)
+	defer h.hub.Unsubscribe(sub)
+	for _, ev := range l {
+		if err := writeEvent(w, ev); err != nil {
+			return
+		}
+	}
+	fl.Flush()
+	tick := time.NewTicker(heartbeat)
+	defer tick.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+		case ev := <-sub.C:
+			err = writeEvent(w, ev)
+		}
+		if err != nil {
+			return
+		}
+		fl.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev *event.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.ID, ev.JSON())
+	return err
+}
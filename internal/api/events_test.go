@@ -0,0 +1,80 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/internal/event"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestEventsHandler(t *testing.T) {
+	check := require.New(t)
+	hub := event.NewHub(10)
+	hub.Publish("r0", "d0", "e0", 1)
+	hub.Publish("r0", "d1", "e0", 2)
+	srv := httptest.NewServer(EventsHandler(hub))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?since=abc")
+	check.NoError(err)
+	resp.Body.Close()
+	check.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"?device=d1", nil)
+	check.NoError(err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err = http.DefaultClient.Do(req)
+	check.NoError(err)
+	defer resp.Body.Close()
+	check.Equal(http.StatusOK, resp.StatusCode)
+	check.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	r := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		id, err := r.ReadString('\n')
+		check.NoError(err)
+		data, err := r.ReadString('\n')
+		check.NoError(err)
+		_, err = r.ReadString('\n')
+		check.NoError(err)
+		return id + data
+	}
+	check.Contains(readEvent(), "id: 2\ndata: {\"id\":2,")
+
+	hub.Publish("r0", "d0", "e0", 3)
+	hub.Publish("r0", "d1", "e1", 4)
+	check.Contains(readEvent(), `"device":"d1","event":"e1","data":4}`)
+}
+
+func TestEventsHandlerHeartbeat(t *testing.T) {
+	check := require.New(t)
+	hb := heartbeat
+	heartbeat = 10 * time.Millisecond
+	defer func() { heartbeat = hb }()
+	srv := httptest.NewServer(EventsHandler(event.NewHub(10)))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	check.NoError(err)
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	check.NoError(err)
+	check.Equal(": ping\n", line)
+}
+
+type noFlushWriter struct {
+	http.ResponseWriter
+}
+
+func TestEventsHandlerNoFlush(t *testing.T) {
+	check := require.New(t)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	EventsHandler(event.NewHub(10)).ServeHTTP(noFlushWriter{w}, r)
+	check.Equal(http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/munbot/master/internal/metrics"
+	"github.com/munbot/master/log"
+)
+
+var requestsTotal = metrics.NewCounter("munbot_api_requests_total",
+	"Api requests by method and status code.", "method", "code")
+
+var requestDuration = metrics.NewHistogram("munbot_api_request_duration_seconds",
+	"Api requests latency.", nil)
+
+func init() {
+	metrics.Register(requestsTotal)
+	metrics.Register(requestDuration)
+}
+
+// HealthStatus is the health and readiness checks response.
+type HealthStatus struct {
+	Status   string          `json:"status"`
+	State    string          `json:"state"`
+	Services map[string]bool `json:"services,omitempty"`
+}
+
+func writeHealth(w http.ResponseWriter, ok bool, st *HealthStatus) {
+	status := http.StatusOK
+	st.Status = "ok"
+	if !ok {
+		status = http.StatusServiceUnavailable
+		st.Status = "fail"
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(st); err != nil {
+		log.Errorf("api write error: %v", err)
+	}
+}
+
+// healthz reports if the core is alive, initializing or running.
+func (a *Api) healthz(w http.ResponseWriter, r *http.Request) {
+	if a.health == nil {
+		writeHealth(w, true, &HealthStatus{})
+		return
+	}
+	writeHealth(w, a.health.Alive(), &HealthStatus{State: a.health.State()})
+}
+
+// readyz reports if the core is running and all its services were started.
+func (a *Api) readyz(w http.ResponseWriter, r *http.Request) {
+	if a.health == nil {
+		writeHealth(w, a.Ready(), &HealthStatus{})
+		return
+	}
+	writeHealth(w, a.health.Ready(), &HealthStatus{
+		State:    a.health.State(),
+		Services: a.health.Services(),
+	})
+}
+
+// statusWriter records the response status code.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client, if the underlying writer
+// supports it.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// metricsHandler counts the requests and observes their latency.
+func metricsHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		if sw.code == 0 {
+			sw.code = http.StatusOK
+		}
+		requestDuration.Observe(time.Since(start).Seconds())
+		requestsTotal.Inc(methodLabel(r.Method), strconv.Itoa(sw.code))
+	})
+}
+
+// methodLabel limits the method label to the standard methods, so clients can
+// not grow the metric labels.
+func methodLabel(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return m
+	}
+	return "OTHER"
+}
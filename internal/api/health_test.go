@@ -0,0 +1,92 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+type testHealth struct {
+	state string
+	svc   map[string]bool
+}
+
+func (h *testHealth) State() string {
+	return h.state
+}
+
+func (h *testHealth) Alive() bool {
+	return h.state != "Halt"
+}
+
+func (h *testHealth) Ready() bool {
+	for _, ok := range h.svc {
+		if !ok {
+			return false
+		}
+	}
+	return h.state == "Run"
+}
+
+func (h *testHealth) Services() map[string]bool {
+	return h.svc
+}
+
+func TestHealth(t *testing.T) {
+	check := require.New(t)
+	hc := &testHealth{state: "Init", svc: map[string]bool{"api": true, "console": false}}
+	a := New().(*Api)
+	check.NoError(a.Configure(&ServerConfig{Net: "tcp", Health: hc}))
+	do := func(path string) (int, *HealthStatus) {
+		w := httptest.NewRecorder()
+		a.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		st := &HealthStatus{}
+		check.NoError(json.Unmarshal(w.Body.Bytes(), st))
+		return w.Code, st
+	}
+
+	code, st := do("/healthz")
+	check.Equal(http.StatusOK, code)
+	check.Equal(&HealthStatus{Status: "ok", State: "Init"}, st)
+	code, st = do("/readyz")
+	check.Equal(http.StatusServiceUnavailable, code)
+	check.Equal("fail", st.Status)
+	check.Equal(hc.svc, st.Services)
+
+	hc.state = "Run"
+	hc.svc["console"] = true
+	code, st = do("/readyz")
+	check.Equal(http.StatusOK, code)
+	check.Equal("Run", st.State)
+
+	hc.state = "Halt"
+	code, _ = do("/healthz")
+	check.Equal(http.StatusServiceUnavailable, code)
+	check.True(requestsTotal.Value("GET", "503") >= 2)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	check := require.New(t)
+	a := New().(*Api)
+	check.NoError(a.Configure(&ServerConfig{Net: "tcp"}))
+	a.Mount("/events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+	}))
+	n := requestsTotal.Value("OTHER", "200")
+	w := httptest.NewRecorder()
+	a.server.Handler.ServeHTTP(w, httptest.NewRequest("BREW", "/events", nil))
+	check.True(w.Flushed, "flush through status writer")
+	check.Equal(n+1, requestsTotal.Value("OTHER", "200"))
+
+	w = httptest.NewRecorder()
+	a.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	check.Equal(http.StatusOK, w.Code)
+	check.Contains(w.Body.String(), "# TYPE munbot_api_request_duration_seconds histogram\n")
+	check.Contains(w.Body.String(), `munbot_api_requests_total{method="OTHER",code="200"}`)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/munbot/master/log"
+)
+
+var logTailSize int = 500
+
+// logTail keeps the last log lines and sends new ones to its followers.
+type logTail struct {
+	mu    sync.Mutex
+	lines []string
+	part  []byte
+	subs  map[chan string]bool
+}
+
+func newLogTail() *logTail {
+	return &logTail{subs: make(map[chan string]bool)}
+}
+
+// Write implements io.Writer. It must not log anything.
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.part = append(t.part, p...)
+	for {
+		i := bytes.IndexByte(t.part, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(t.part[:i+1])
+		t.part = t.part[i+1:]
+		t.lines = append(t.lines, line)
+		if len(t.lines) > logTailSize {
+			t.lines = t.lines[len(t.lines)-logTailSize:]
+		}
+		for c := range t.subs {
+			select {
+			case c <- line:
+			default:
+				// slow follower, drop the line
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// last returns up to n of the last log lines and, if follow is true, a channel
+// where new lines will be sent.
+func (t *logTail) last(n int, follow bool) ([]string, chan string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var c chan string
+	if follow {
+		c = make(chan string, 100)
+		t.subs[c] = true
+	}
+	if n < 0 || n > len(t.lines) {
+		n = len(t.lines)
+	}
+	l := make([]string, n)
+	copy(l, t.lines[len(t.lines)-n:])
+	return l, c
+}
+
+func (t *logTail) unfollow(c chan string) {
+	t.mu.Lock()
+	delete(t.subs, c)
+	t.mu.Unlock()
+}
+
+// LogsHandler returns a handler showing master's log. From then on, log output
+// is also written to it. The n query parameter sets how many lines to show
+// (default 100) and if follow=true new lines will be sent until the client
+// closes the connection.
+func LogsHandler() http.Handler {
+	t := newLogTail()
+	log.SetOutput(io.MultiWriter(log.Writer(), t))
+	return t
+}
+
+func (t *logTail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	n := 100
+	if s := q.Get("n"); s != "" {
+		var err error
+		n, err = strconv.Atoi(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid n: %s", s)
+			return
+		}
+	}
+	follow, _ := strconv.ParseBool(q.Get("follow"))
+	var f http.Flusher
+	if follow {
+		var ok bool
+		f, ok = streamWriter(w, r)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "streaming not supported")
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	lines, c := t.last(n, follow)
+	if follow {
+		defer t.unfollow(c)
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return
+		}
+	}
+	if !follow {
+		return
+	}
+	f.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-c:
+			if _, err := io.WriteString(w, line); err != nil {
+				return
+			}
+			f.Flush()
+		}
+	}
+}
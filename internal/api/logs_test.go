@@ -0,0 +1,64 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestLogTail(t *testing.T) {
+	check := require.New(t)
+	tail := newLogTail()
+	fmt.Fprint(tail, "line 0\nline")
+	fmt.Fprint(tail, " 1\n")
+	lines, c := tail.last(-1, false)
+	check.Nil(c)
+	check.Equal([]string{"line 0\n", "line 1\n"}, lines)
+
+	lines, c = tail.last(1, true)
+	check.Equal([]string{"line 1\n"}, lines)
+	fmt.Fprint(tail, "line 2\n")
+	check.Equal("line 2\n", <-c)
+	tail.unfollow(c)
+	check.Len(tail.subs, 0)
+
+	for i := 0; i < logTailSize; i++ {
+		fmt.Fprintf(tail, "line %d\n", i+3)
+	}
+	lines, _ = tail.last(-1, false)
+	check.Len(lines, logTailSize)
+	check.Equal("line 3\n", lines[0])
+}
+
+func TestLogsHandler(t *testing.T) {
+	check := require.New(t)
+	tail := newLogTail()
+	fmt.Fprint(tail, "line 0\nline 1\n")
+	srv := httptest.NewServer(tail)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?n=abc")
+	check.NoError(err)
+	resp.Body.Close()
+	check.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "?n=1&follow=true")
+	check.NoError(err)
+	defer resp.Body.Close()
+	check.Equal(http.StatusOK, resp.StatusCode)
+	r := bufio.NewReader(resp.Body)
+	line, err := r.ReadString('\n')
+	check.NoError(err)
+	check.Equal("line 1\n", line)
+	fmt.Fprint(tail, "line 2\n")
+	line, err = r.ReadString('\n')
+	check.NoError(err)
+	check.Equal("line 2\n", line)
+}
@@ -0,0 +1,207 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package mbctl implements master remote control cmd util.
+package mbctl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/munbot/master/cmd"
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/api/client"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/vfs"
+)
+
+const usage string = `commands:
+  status                                  show master status
+  stop                                    stop master
+  reload                                  reload master configuration
+  robots                                  list robots
+  devices robot                           list robot's devices
+  cmd robot device command [key=value...] run a device command
+  logs [-f] [-n lines]                    show master log
+  pid                                     show master process id
+  signal hup|int|quit|term                send a signal to master process`
+
+// Cmd is the mb ctl command. It sends commands to the running master via its
+// api server, using the profile's unix socket if present or tcp otherwise.
+type Cmd struct {
+	json  bool
+	token string
+}
+
+func New() *Cmd {
+	return &Cmd{}
+}
+
+func (c *Cmd) FlagSet(fs *flag.FlagSet) {
+	fs.BoolVar(&c.json, "json", false, "json output")
+	fs.StringVar(&c.token, "token", "", "api `token` (default: $MBAPI_TOKEN)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s [options] command [args...]\n\n%s\n\noptions:\n",
+			fs.Name(), usage)
+		fs.PrintDefaults()
+	}
+}
+
+func (c *Cmd) Command(flags *config.Flags) cmd.Command {
+	return &Main{cmd: c, flags: flags, out: os.Stdout}
+}
+
+type Main struct {
+	cmd   *Cmd
+	flags *config.Flags
+	recv  *client.HTTP
+	out   io.Writer
+}
+
+// connect returns the api receiver for the running master.
+func (m *Main) connect() (*client.HTTP, error) {
+	sock := m.flags.Profile.GetRundirPath("api.socket")
+	if env.Get("MBAPI_NET") == "unix" || vfs.Exist(sock) {
+		log.Debugf("api socket %s", sock)
+		return client.NewUnix(sock), nil
+	}
+	addr := env.Get("MBAPI_ADDR")
+	if ip := net.ParseIP(addr); addr == "" || (ip != nil && ip.IsUnspecified()) {
+		addr = "127.0.0.1"
+	}
+	host := net.JoinHostPort(addr, strconv.FormatUint(uint64(env.GetUint("MBAPI_PORT")), 10))
+	if !env.GetBool("MBAPI_TLS") {
+		log.Debugf("api http://%s", host)
+		return client.NewHTTP("http://" + host), nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	cafn := filepath.Join(m.flags.Profile.GetPath("auth"), "ca.crt")
+	if blob, err := vfs.ReadFile(cafn); err == nil {
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(blob) {
+			return nil, fmt.Errorf("%s: no certificates found", cafn)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	log.Debugf("api https://%s", host)
+	return client.NewHTTPS("https://"+host, cfg), nil
+}
+
+func (m *Main) Run(args []string) int {
+	if len(args) == 0 {
+		log.Errorf("no command; check %s ctl -help", os.Args[0])
+		return 1
+	}
+	// these use the profile pidfile instead of the api
+	switch args[0] {
+	case "pid":
+		return m.pid(args[1:])
+	case "signal":
+		return m.signal(args[1:])
+	}
+	if m.recv == nil {
+		var err error
+		if m.recv, err = m.connect(); err != nil {
+			log.Error(err)
+			return 2
+		}
+	}
+	token := m.cmd.token
+	if token == "" {
+		token = env.Get("MBAPI_TOKEN")
+	}
+	m.recv.SetToken(token)
+	if args[0] == "logs" {
+		return m.logs(args[1:])
+	}
+	var req client.Request
+	switch args[0] {
+	case "stop":
+		if len(args) == 1 {
+			req = &client.ExitCmd{}
+		}
+	case "exit":
+		// use stop instead
+	default:
+		var err error
+		if req, err = client.ParseArgs(args); err != nil && err != client.ErrUsage {
+			log.Error(err)
+			return 1
+		}
+	}
+	if req == nil {
+		log.Errorf("invalid arguments: %v; check %s ctl -help", args, os.Args[0])
+		return 1
+	}
+	resp := client.New(m.recv, env.Get("MBAPI_PATH")).Exec(req)
+	if err := m.print(resp); err != nil {
+		log.Error(err)
+		return 3
+	}
+	if resp.Err() != nil {
+		return 2
+	}
+	return 0
+}
+
+func (m *Main) print(resp client.Response) error {
+	if !m.cmd.json {
+		_, err := io.WriteString(m.out, resp.FormatText())
+		return err
+	}
+	var v interface{} = resp
+	if err := resp.Err(); err != nil {
+		v = master.Error{Msg: err.Error()}
+	}
+	enc := json.NewEncoder(m.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (m *Main) logs(args []string) int {
+	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	follow := fs.Bool("f", false, "follow log output")
+	lines := fs.Int("n", 100, "number of `lines` to show")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		log.Errorf("invalid arguments: %v; check %s ctl -help", args, os.Args[0])
+		return 1
+	}
+	q := url.Values{}
+	q.Set("n", strconv.Itoa(*lines))
+	if *follow {
+		q.Set("follow", "true")
+	}
+	resp, err := m.recv.Stream(path.Join(env.Get("MBAPI_PATH"), "logs") + "?" + q.Encode())
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		e := master.Error{}
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Msg == "" {
+			e.Msg = resp.Status
+		}
+		log.Errorf("api: %s", e.Msg)
+		return 2
+	}
+	if _, err := io.Copy(m.out, resp.Body); err != nil {
+		log.Error(err)
+		return 2
+	}
+	return 0
+}
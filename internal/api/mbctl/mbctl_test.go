@@ -0,0 +1,130 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package mbctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/config/profile"
+	"github.com/munbot/master/internal/api"
+	"github.com/munbot/master/internal/api/client"
+	"github.com/munbot/master/internal/api/wapp"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/testing/require"
+	"github.com/munbot/master/utils/pidfile"
+)
+
+func newTestMain(t *testing.T, cmd *Cmd) (*Main, *bytes.Buffer, func()) {
+	m := master.NewRobot()
+	if err := m.Configure(&master.Config{}, &wapp.Config{Enable: true, Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/logs", api.LogsHandler())
+	mux.Handle("/", m)
+	srv := httptest.NewServer(mux)
+	buf := new(bytes.Buffer)
+	return &Main{cmd: cmd, recv: client.NewHTTP(srv.URL), out: buf}, buf, srv.Close
+}
+
+func TestCtl(t *testing.T) {
+	check := require.New(t)
+	m, buf, done := newTestMain(t, New())
+	defer done()
+
+	check.Equal(1, m.Run([]string{}))
+	check.Equal(1, m.Run([]string{"exit"}))
+	check.Equal(1, m.Run([]string{"devices"}))
+	check.Equal(1, m.Run([]string{"testing"}))
+
+	check.Equal(0, m.Run([]string{"status"}))
+	check.Contains(buf.String(), "Status: ok\n")
+
+	buf.Reset()
+	check.Equal(0, m.Run([]string{"robots"}))
+	check.Equal("Munbot devices:1 connections:1 commands:0\n", buf.String())
+
+	buf.Reset()
+	check.Equal(2, m.Run([]string{"stop"}))
+	check.Equal("ERROR: nothing to do here\n", buf.String())
+
+	buf.Reset()
+	check.Equal(2, m.Run([]string{"reload"}))
+	check.Equal("ERROR: master: reload not available\n", buf.String())
+}
+
+func TestCtlJSON(t *testing.T) {
+	check := require.New(t)
+	m, buf, done := newTestMain(t, &Cmd{json: true})
+	defer done()
+
+	check.Equal(0, m.Run([]string{"devices", "Munbot"}))
+	resp := new(client.DevicesResponse)
+	check.NoError(json.Unmarshal(buf.Bytes(), resp))
+	check.Len(resp.Devices, 1)
+	check.Equal("munbot", resp.Devices[0].Name)
+
+	buf.Reset()
+	check.Equal(2, m.Run([]string{"devices", "Nobot"}))
+	e := master.Error{}
+	check.NoError(json.Unmarshal(buf.Bytes(), &e))
+	check.Equal("No Robot found with the name Nobot", e.Msg)
+}
+
+func TestCtlLogs(t *testing.T) {
+	check := require.New(t)
+	m, buf, done := newTestMain(t, New())
+	defer done()
+	log.Print("testing mbctl logs")
+	check.Equal(1, m.Run([]string{"logs", "-x"}))
+	check.Equal(0, m.Run([]string{"logs", "-n", "10"}))
+	check.Contains(buf.String(), "testing mbctl logs")
+	check.NotContains(buf.String(), "\n\n")
+}
+
+func TestCtlSignal(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_mbctl_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	buf := new(bytes.Buffer)
+	prof := &profile.Profile{Name: "testing", Run: dir}
+	check.NoError(os.MkdirAll(prof.GetRundir(), 0750))
+	m := &Main{cmd: New(), flags: &config.Flags{Profile: prof}, out: buf}
+
+	check.Equal(2, m.Run([]string{"pid"}))
+	check.Equal(1, m.Run([]string{"pid", "now"}))
+	check.Equal(1, m.Run([]string{"signal"}))
+	check.Equal(1, m.Run([]string{"signal", "kill"}))
+	check.Equal(2, m.Run([]string{"signal", "hup"}))
+
+	f, err := pidfile.Lock(prof.GetRundirPath("master.pid"))
+	check.NoError(err)
+	defer f.Unlock()
+	check.Equal(0, m.Run([]string{"pid"}))
+	check.Equal(fmt.Sprintf("%d\n", os.Getpid()), buf.String())
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	defer signal.Stop(c)
+	buf.Reset()
+	check.Equal(0, m.Run([]string{"signal", "SIGHUP"}))
+	check.Equal(fmt.Sprintf("SIGHUP sent to pid %d\n", os.Getpid()), buf.String())
+	check.Equal(syscall.SIGHUP, <-c)
+}
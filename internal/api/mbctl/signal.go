@@ -0,0 +1,95 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package mbctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/utils/pidfile"
+)
+
+// signals are the ones handled by the running master.
+var signals map[string]os.Signal = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+}
+
+// running returns the pid of the master holding the profile lock.
+func (m *Main) running() (int, error) {
+	fn := m.flags.Profile.GetRundirPath("master.pid")
+	pid, err := pidfile.Running(fn)
+	if err != nil {
+		if err == pidfile.ErrNotRunning {
+			return 0, fmt.Errorf("profile %s: master is not running", m.flags.Profile.Name)
+		}
+		return 0, err
+	}
+	return pid, nil
+}
+
+func (m *Main) pid(args []string) int {
+	if len(args) > 0 {
+		log.Errorf("invalid arguments: %v; check %s ctl -help", args, os.Args[0])
+		return 1
+	}
+	pid, err := m.running()
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	if m.cmd.json {
+		return m.printJSON(map[string]int{"pid": pid})
+	}
+	fmt.Fprintf(m.out, "%d\n", pid)
+	return 0
+}
+
+func (m *Main) signal(args []string) int {
+	if len(args) != 1 {
+		log.Errorf("invalid arguments: %v; check %s ctl -help", args, os.Args[0])
+		return 1
+	}
+	name := strings.TrimPrefix(strings.ToUpper(args[0]), "SIG")
+	sig, ok := signals[name]
+	if !ok {
+		log.Errorf("invalid signal: %s", args[0])
+		return 1
+	}
+	pid, err := m.running()
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	if err := p.Signal(sig); err != nil {
+		log.Errorf("signal %s pid %d: %v", name, pid, err)
+		return 2
+	}
+	if m.cmd.json {
+		return m.printJSON(map[string]interface{}{"pid": pid, "signal": "SIG" + name})
+	}
+	fmt.Fprintf(m.out, "SIG%s sent to pid %d\n", name, pid)
+	return 0
+}
+
+func (m *Main) printJSON(v interface{}) int {
+	enc := json.NewEncoder(m.out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Error(err)
+		return 3
+	}
+	return 0
+}
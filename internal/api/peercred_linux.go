@@ -0,0 +1,28 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCred gets the unix socket peer process credentials using SO_PEERCRED.
+func peerCred(c *net.UnixConn) (*PeerCred, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var cred *syscall.Ucred
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, serr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if serr != nil {
+		return nil, serr
+	}
+	return &PeerCred{Pid: cred.Pid, Uid: cred.Uid, Gid: cred.Gid}, nil
+}
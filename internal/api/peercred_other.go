@@ -0,0 +1,17 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package api
+
+import (
+	"errors"
+	"net"
+)
+
+// peerCred is only implemented on linux.
+func peerCred(c *net.UnixConn) (*PeerCred, error) {
+	return nil, errors.New("api: peer credentials not supported")
+}
@@ -5,6 +5,9 @@ package api
 
 import (
 	"net/http"
+	"os"
+
+	"github.com/munbot/master/internal/auth"
 )
 
 type ServerConfig struct {
@@ -12,6 +15,27 @@ type ServerConfig struct {
 	Net    string
 	Addr   string
 	Port   uint
+	Auth   auth.Manager
+	// TLS settings. Relative filenames are resolved from profile dir.
+	TLS         bool
+	TLSCert     string
+	TLSKey      string
+	TLSClients  bool
+	TLSClientCA string
+	TLSRoles    string
+	// Health reports the core state for the health and readiness checks.
+	Health Health
+	// ListenFile is a socket passed by the service manager. If not nil, it's
+	// used instead of listening on Net and Addr.
+	ListenFile *os.File
+}
+
+// Health is implemented by the runtime core to report its state.
+type Health interface {
+	State() string
+	Alive() bool
+	Ready() bool
+	Services() map[string]bool
 }
 
 type Server interface {
@@ -19,4 +43,5 @@ type Server interface {
 	Start() error
 	Stop() error
 	Mount(path string, handler http.Handler)
+	Ready() bool
 }
@@ -0,0 +1,136 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/munbot/master/config/profile"
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+// profilePath returns the named file path, relative to profile dir if it's
+// not an absolute path already.
+func profilePath(prof *profile.Profile, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return prof.GetPath(name)
+}
+
+// loadClientRoles parses the client certificates roles file. Each line has a
+// role name followed by a certificate subject, like: operator CN=bot,O=munbot
+func loadClientRoles(fn string) (map[string]auth.Role, error) {
+	roles := map[string]auth.Role{}
+	blob, err := vfs.ReadFile(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Warn(err)
+			return roles, nil
+		}
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(blob))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.SplitN(line, " ", 2)
+		if len(f) != 2 {
+			return nil, fmt.Errorf("%s: invalid line: %s", fn, line)
+		}
+		r, err := auth.ParseRole(f[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fn, err)
+		}
+		roles[strings.TrimSpace(f[1])] = r
+	}
+	return roles, sc.Err()
+}
+
+func (a *Api) setupTLS(c *ServerConfig) error {
+	prof := profile.New()
+	certfn := profilePath(prof, c.TLSCert)
+	keyfn := profilePath(prof, c.TLSKey)
+	if !vfs.Exist(certfn) && !vfs.Exist(keyfn) {
+		if c.Auth == nil {
+			return fmt.Errorf("api: tls certificate not found: %s", certfn)
+		}
+		hosts := []string{"localhost", "127.0.0.1", "::1", env.Get("MUNBOT"), c.Addr}
+		if err := c.Auth.NewServerCert(certfn, keyfn, hosts); err != nil {
+			return err
+		}
+	}
+	certPEM, err := vfs.ReadFile(certfn)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := vfs.ReadFile(keyfn)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("api: %s: %v", certfn, err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	a.roles = nil
+	if c.TLSClients {
+		cafn := c.TLSClientCA
+		if cafn == "" {
+			if c.Auth == nil {
+				return fmt.Errorf("api: no tls client CA")
+			}
+			cafn = c.Auth.CACertFile()
+		} else {
+			cafn = profilePath(prof, cafn)
+		}
+		blob, err := vfs.ReadFile(cafn)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(blob) {
+			return fmt.Errorf("api: %s: no certificates found", cafn)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		a.roles, err = loadClientRoles(profilePath(prof, c.TLSRoles))
+		if err != nil {
+			return err
+		}
+		log.Debugf("tls client CA %s, %d roles", cafn, len(a.roles))
+	}
+	a.tls = cfg
+	return nil
+}
+
+// certRole returns the role mapped to the request verified client certificate
+// subject, if any.
+func (a *Api) certRole(r *http.Request) (auth.Role, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return auth.RoleNone, false
+	}
+	subject := r.TLS.VerifiedChains[0][0].Subject.String()
+	role, found := a.roles[subject]
+	if !found {
+		log.Debugf("api tls client %q has no role", subject)
+	}
+	return role, found
+}
@@ -0,0 +1,109 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/testing/require"
+)
+
+func newTestClientCert(t *testing.T, cn string) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+func TestTLS(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_api_tls_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	am := auth.New()
+	check.NoError(am.Configure(dir))
+
+	client, clientCert := newTestClientCert(t, "testing")
+	cafn := filepath.Join(dir, "clients.crt")
+	check.NoError(ioutil.WriteFile(cafn, []byte(pemCert(clientCert)), 0644))
+	rolesfn := filepath.Join(dir, "roles")
+	check.NoError(ioutil.WriteFile(rolesfn, []byte("# testing\noperator CN=testing\n"), 0644))
+
+	a := &Api{auth: am}
+	check.NoError(a.setupTLS(&ServerConfig{
+		Addr:        "127.0.0.1",
+		Auth:        am,
+		TLS:         true,
+		TLSCert:     filepath.Join(dir, "api.crt"),
+		TLSKey:      filepath.Join(dir, "api.key"),
+		TLSClients:  true,
+		TLSClientCA: cafn,
+		TLSRoles:    rolesfn,
+	}))
+	check.Equal(auth.Operator, a.roles["CN=testing"])
+
+	srv := httptest.NewUnstartedServer(a.authHandler(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+	srv.TLS = a.tls
+	srv.StartTLS()
+	defer srv.Close()
+
+	caPEM, err := ioutil.ReadFile(am.CACertFile())
+	check.NoError(err)
+	roots := x509.NewCertPool()
+	check.True(roots.AppendCertsFromPEM(caPEM))
+	get := func(certs []tls.Certificate, path string) int {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+		}}}
+		resp, err := c.Get(srv.URL + path)
+		check.NoError(err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	check.Equal(http.StatusUnauthorized, get(nil, "/api/robots"), "no client cert")
+	check.Equal(http.StatusOK, get([]tls.Certificate{client}, "/api/robots/r0/commands/c0"))
+	check.Equal(http.StatusForbidden, get([]tls.Certificate{client}, "/api/commands/exit"))
+}
+
+func pemCert(c *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}))
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+var socketMode os.FileMode = 0660
+
+type ctxKey int
+
+const (
+	ctxPeer ctxKey = iota
+	ctxConn
+)
+
+// PeerCred holds the credentials of a unix socket peer process.
+type PeerCred struct {
+	Pid int32
+	Uid uint32
+	Gid uint32
+}
+
+func (p *PeerCred) String() string {
+	return fmt.Sprintf("pid:%d uid:%d gid:%d", p.Pid, p.Uid, p.Gid)
+}
+
+// listenUnix listens on the named unix socket. If the socket file already
+// exists and nobody is answering on it, it's a leftover from a previous run
+// and it's removed first.
+func listenUnix(fn string) (net.Listener, error) {
+	if _, err := os.Lstat(fn); err == nil {
+		if c, err := net.DialTimeout("unix", fn, time.Second); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("api: socket %s already in use", fn)
+		}
+		log.Warnf("Api remove stale socket %s", fn)
+		if err := os.Remove(fn); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", fn)
+	if err != nil {
+		return nil, err
+	}
+	if err := vfs.Chmod(fn, socketMode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// removeSocket removes the unix socket file, if it was not already removed
+// when the listener was closed.
+func removeSocket(fn string) error {
+	if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// connContext saves the connection and unix socket peer credentials in the
+// connection context, so they can be used later by the handlers.
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	ctx = context.WithValue(ctx, ctxConn, c)
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	cred, err := peerCred(uc)
+	if err != nil {
+		log.Debugf("api unix peer credentials: %v", err)
+		return ctx
+	}
+	log.Printf("Api unix peer %s", cred)
+	return context.WithValue(ctx, ctxPeer, cred)
+}
+
+// peerRole returns admin role if the request came through the unix socket from
+// a process owned by the same user running master, or by root. Any other local
+// user, allowed by the socket group permissions, needs a bearer token.
+func peerRole(r *http.Request) (auth.Role, bool) {
+	cred, ok := r.Context().Value(ctxPeer).(*PeerCred)
+	if !ok {
+		return auth.RoleNone, false
+	}
+	if cred.Uid == 0 || cred.Uid == uint32(os.Getuid()) {
+		return auth.Admin, true
+	}
+	log.Debugf("api unix peer %s has no role", cred)
+	return auth.RoleNone, false
+}
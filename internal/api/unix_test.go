@@ -0,0 +1,68 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/munbot/master/internal/api/client"
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestUnixSocket(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_api_unix_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	am := auth.New()
+	check.NoError(am.Configure(dir))
+
+	fn := filepath.Join(dir, "api.socket")
+	// stale socket file
+	check.NoError(ioutil.WriteFile(fn, []byte{}, 0644))
+
+	a := New().(*Api)
+	a.enable = true
+	a.net = "unix"
+	a.auth = am
+	a.server.Addr = fn
+	a.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	a.ln, err = listenUnix(fn)
+	check.NoError(err)
+	done := make(chan error, 1)
+	go func() {
+		done <- a.server.Serve(a.ln)
+	}()
+
+	st, err := os.Stat(fn)
+	check.NoError(err)
+	check.Equal(os.ModeSocket, st.Mode()&os.ModeSocket)
+	check.Equal(socketMode, st.Mode().Perm())
+
+	_, err = listenUnix(fn)
+	check.Error(err, "socket in use")
+
+	c := client.NewUnix(fn)
+	resp, err := c.GET("/")
+	check.NoError(err)
+	resp.Body.Close()
+	if runtime.GOOS == "linux" {
+		check.Equal(http.StatusOK, resp.StatusCode)
+	} else {
+		check.Equal(http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	check.NoError(a.Stop())
+	check.Equal(http.ErrServerClosed, <-done)
+	_, err = os.Stat(fn)
+	check.True(os.IsNotExist(err), "socket removed")
+}
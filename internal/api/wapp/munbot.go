@@ -0,0 +1,308 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package wapp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/version"
+)
+
+// ErrNotImplemented is returned by Runtime actions not supported yet.
+var ErrNotImplemented error = errors.New("not implemented")
+
+// Runtime provides the core runtime information and lifecycle actions for the
+// munbot api.
+type Runtime interface {
+	Name() string
+	State() string
+	Uptime() time.Duration
+	Profile() string
+	Env() map[string]string
+	Config() map[string]string
+	Explain(filter string) []*ExplainInfo
+	Sessions() []*auth.SessionInfo
+	Services() []*ServiceInfo
+	Transitions() []*TransitionInfo
+	Reload() error
+	Stop() error
+}
+
+// Redacted replaces the value of the settings considered secrets.
+const Redacted string = "<redacted>"
+
+var secretWords []string = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD"}
+
+func isSecret(key string) bool {
+	key = strings.ToUpper(key)
+	for _, w := range secretWords {
+		if strings.Contains(key, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(m map[string]string) map[string]string {
+	r := make(map[string]string, len(m))
+	for k, v := range m {
+		if v != "" && isSecret(k) {
+			v = Redacted
+		}
+		r[k] = v
+	}
+	return r
+}
+
+// VersionInfo is the version endpoint response.
+type VersionInfo struct {
+	Version string    `json:"version"`
+	Major   int       `json:"major"`
+	Minor   int       `json:"minor"`
+	Patch   int       `json:"patch"`
+	Build   BuildInfo `json:"build"`
+}
+
+// BuildInfo is the version endpoint build details.
+type BuildInfo struct {
+	Date string   `json:"date"`
+	OS   string   `json:"os"`
+	Arch string   `json:"arch"`
+	Tags []string `json:"tags"`
+}
+
+// StatusInfo is the status endpoint response.
+type StatusInfo struct {
+	Name     string            `json:"name"`
+	State    string            `json:"state"`
+	Profile  string            `json:"profile"`
+	Uptime   string            `json:"uptime"`
+	Seconds  float64           `json:"uptime_seconds"`
+	Services []*ServiceInfo    `json:"services,omitempty"`
+	History  []*TransitionInfo `json:"history,omitempty"`
+}
+
+// ServiceInfo is a core runtime service status.
+type ServiceInfo struct {
+	Name     string    `json:"name"`
+	Status   string    `json:"status"`
+	Restarts int       `json:"restarts"`
+	Since    time.Time `json:"since"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// TransitionInfo is a core state change record.
+type TransitionInfo struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Time  time.Time `json:"time"`
+	Cause string    `json:"cause,omitempty"`
+}
+
+// ConfigInfo is the config endpoint response.
+type ConfigInfo struct {
+	Profile string            `json:"profile"`
+	Env     map[string]string `json:"env"`
+	Config  map[string]string `json:"config"`
+}
+
+// ExplainInfo is a config option or env setting value, the source it comes
+// from and the lower precedence values it overrides.
+type ExplainInfo struct {
+	Name      string       `json:"name"`
+	Value     string       `json:"value"`
+	Source    string       `json:"source"`
+	Overrides []*LayerInfo `json:"overrides,omitempty"`
+}
+
+// LayerInfo is a value set by a source.
+type LayerInfo struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+}
+
+// ActionInfo is the lifecycle endpoints response.
+type ActionInfo struct {
+	Action string `json:"action"`
+	Status string `json:"status"`
+}
+
+type munbotError struct {
+	Msg string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		log.Errorf("munbot api write: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, &munbotError{Msg: msg})
+}
+
+// munbot implements the versioned munbot api router.
+type munbot struct {
+	rt Runtime
+}
+
+func newMunbot(rt Runtime) http.Handler {
+	m := &munbot{rt: rt}
+	r := mux.NewRouter()
+	r.HandleFunc("/openapi.json", m.openapi).Methods(http.MethodGet)
+	r.HandleFunc("/version", m.version).Methods(http.MethodGet)
+	r.HandleFunc("/status", m.status).Methods(http.MethodGet)
+	r.HandleFunc("/config", m.config).Methods(http.MethodGet)
+	r.HandleFunc("/config/explain", m.explain).Methods(http.MethodGet)
+	r.HandleFunc("/sessions", m.sessions).Methods(http.MethodGet)
+	r.HandleFunc("/reload", m.reload).Methods(http.MethodPost)
+	r.HandleFunc("/stop", m.stop).Methods(http.MethodPost)
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not found")
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	})
+	return r
+}
+
+func (m *munbot) openapi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := w.Write([]byte(openapiDoc)); err != nil {
+		log.Errorf("munbot api write: %v", err)
+	}
+}
+
+func (m *munbot) version(w http.ResponseWriter, r *http.Request) {
+	v := new(version.Info)
+	b := v.Build()
+	tags := b.Tags()
+	sort.Strings(tags)
+	writeJSON(w, http.StatusOK, &VersionInfo{
+		Version: v.String(),
+		Major:   v.Major(),
+		Minor:   v.Minor(),
+		Patch:   v.Patch(),
+		Build: BuildInfo{
+			Date: b.Date(),
+			OS:   b.OS(),
+			Arch: b.Arch(),
+			Tags: tags,
+		},
+	})
+}
+
+// runtime checks there's a runtime attached, writing an error if not.
+func (m *munbot) runtime(w http.ResponseWriter) bool {
+	if m.rt == nil {
+		writeError(w, http.StatusServiceUnavailable, "runtime not available")
+		return false
+	}
+	return true
+}
+
+func (m *munbot) status(w http.ResponseWriter, r *http.Request) {
+	if !m.runtime(w) {
+		return
+	}
+	up := m.rt.Uptime()
+	writeJSON(w, http.StatusOK, &StatusInfo{
+		Name:     m.rt.Name(),
+		State:    m.rt.State(),
+		Profile:  m.rt.Profile(),
+		Uptime:   up.String(),
+		Seconds:  up.Seconds(),
+		Services: m.rt.Services(),
+		History:  m.rt.Transitions(),
+	})
+}
+
+func (m *munbot) config(w http.ResponseWriter, r *http.Request) {
+	if !m.runtime(w) {
+		return
+	}
+	writeJSON(w, http.StatusOK, &ConfigInfo{
+		Profile: m.rt.Profile(),
+		Env:     redact(m.rt.Env()),
+		Config:  redact(m.rt.Config()),
+	})
+}
+
+// explain lists where the settings values come from. The name query parameter
+// filters them by name or name prefix.
+func (m *munbot) explain(w http.ResponseWriter, r *http.Request) {
+	if !m.runtime(w) {
+		return
+	}
+	name := r.URL.Query().Get("name")
+	l := m.rt.Explain(name)
+	if len(l) == 0 && name != "" {
+		writeError(w, http.StatusNotFound, name+": not found")
+		return
+	}
+	if l == nil {
+		l = []*ExplainInfo{}
+	}
+	for _, x := range l {
+		if !isSecret(x.Name) {
+			continue
+		}
+		if x.Value != "" {
+			x.Value = Redacted
+		}
+		for _, o := range x.Overrides {
+			if o.Value != "" {
+				o.Value = Redacted
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+func (m *munbot) sessions(w http.ResponseWriter, r *http.Request) {
+	if !m.runtime(w) {
+		return
+	}
+	l := m.rt.Sessions()
+	if l == nil {
+		l = []*auth.SessionInfo{}
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+func (m *munbot) action(w http.ResponseWriter, name string, fn func() error) {
+	if !m.runtime(w) {
+		return
+	}
+	if err := fn(); err != nil {
+		status := http.StatusConflict
+		if err == ErrNotImplemented {
+			status = http.StatusNotImplemented
+		}
+		writeError(w, status, name+": "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, &ActionInfo{Action: name, Status: "accepted"})
+}
+
+func (m *munbot) reload(w http.ResponseWriter, r *http.Request) {
+	m.action(w, "reload", func() error { return m.rt.Reload() })
+}
+
+func (m *munbot) stop(w http.ResponseWriter, r *http.Request) {
+	m.action(w, "stop", func() error { return m.rt.Stop() })
+}
@@ -0,0 +1,156 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package wapp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gobot.io/x/gobot"
+	"gobot.io/x/gobot/api"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/testing/require"
+)
+
+type testRuntime struct {
+	stop error
+}
+
+func (t *testRuntime) Name() string          { return "testing" }
+func (t *testRuntime) State() string         { return "Run" }
+func (t *testRuntime) Uptime() time.Duration { return 90 * time.Second }
+func (t *testRuntime) Profile() string       { return "default" }
+
+func (t *testRuntime) Env() map[string]string {
+	return map[string]string{"MBAPI_TOKEN": "s3cr3t", "MBAPI_PORT": "6490", "MB_SECRET": ""}
+}
+
+func (t *testRuntime) Config() map[string]string {
+	return map[string]string{"master.name": "testing", "api.token": "s3cr3t"}
+}
+
+func (t *testRuntime) Explain(filter string) []*ExplainInfo {
+	l := []*ExplainInfo{
+		{Name: "console.port", Value: "7000", Source: "config.json",
+			Overrides: []*LayerInfo{{Source: "default", Value: "6492"}}},
+		{Name: "MBAPI_TOKEN", Value: "s3cr3t", Source: "os",
+			Overrides: []*LayerInfo{{Source: "default", Value: ""}}},
+	}
+	r := make([]*ExplainInfo, 0)
+	for _, x := range l {
+		if strings.HasPrefix(x.Name, filter) {
+			r = append(r, x)
+		}
+	}
+	return r
+}
+
+func (t *testRuntime) Sessions() []*auth.SessionInfo { return nil }
+func (t *testRuntime) Reload() error                 { return ErrNotImplemented }
+func (t *testRuntime) Stop() error                   { return t.stop }
+
+func (t *testRuntime) Services() []*ServiceInfo {
+	return []*ServiceInfo{
+		{Name: "api", Status: "running", Since: testSince},
+		{Name: "console", Status: "backing-off", Restarts: 2, Since: testSince, Error: "testing"},
+	}
+}
+
+func (t *testRuntime) Transitions() []*TransitionInfo {
+	return []*TransitionInfo{
+		{From: "Dead", To: "Init", Time: testSince, Cause: "new"},
+		{From: "Init", To: "Run", Time: testSince, Cause: "configured"},
+	}
+}
+
+var testSince time.Time = time.Date(2020, 7, 10, 0, 0, 0, 0, time.UTC)
+
+func newTestApi(rt Runtime) Api {
+	a := New(api.NewAPI(gobot.NewMaster()))
+	a.Configure(&Config{Enable: true, Path: "/", Runtime: rt})
+	return a
+}
+
+func TestMunbot(t *testing.T) {
+	check := require.New(t)
+	rt := &testRuntime{}
+	a := newTestApi(rt)
+	do := func(method, path string, v interface{}) int {
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		check.Equal("application/json; charset=utf-8", w.Header().Get("Content-Type"), path)
+		if v != nil {
+			check.NoError(json.Unmarshal(w.Body.Bytes(), v), path)
+		}
+		return w.Code
+	}
+
+	doc := map[string]interface{}{}
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/openapi.json", &doc))
+	check.Equal("3.0.3", doc["openapi"])
+
+	v := &VersionInfo{}
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/version", v))
+	check.NotEmpty(v.Version)
+
+	st := &StatusInfo{}
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/status", st))
+	check.Equal(&StatusInfo{Name: "testing", State: "Run", Profile: "default",
+		Uptime: "1m30s", Seconds: 90, Services: rt.Services(),
+		History: rt.Transitions()}, st)
+
+	e := &munbotError{}
+	cfg := &ConfigInfo{}
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/config", cfg))
+	check.Equal(Redacted, cfg.Env["MBAPI_TOKEN"])
+	check.Equal("6490", cfg.Env["MBAPI_PORT"])
+	check.Equal("", cfg.Env["MB_SECRET"])
+	check.Equal(Redacted, cfg.Config["api.token"])
+	check.Equal("testing", cfg.Config["master.name"])
+
+	var xl []*ExplainInfo
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/config/explain", &xl))
+	check.Len(xl, 2)
+	check.Equal(&ExplainInfo{Name: "console.port", Value: "7000", Source: "config.json",
+		Overrides: []*LayerInfo{{Source: "default", Value: "6492"}}}, xl[0])
+	check.Equal(Redacted, xl[1].Value)
+	check.Equal("", xl[1].Overrides[0].Value)
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/config/explain?name=console", &xl))
+	check.Len(xl, 1)
+	check.Equal(http.StatusNotFound, do("GET", "/munbot/v1/config/explain?name=nothing", e))
+	check.Equal("nothing: not found", e.Msg)
+
+	var sess []*auth.SessionInfo
+	check.Equal(http.StatusOK, do("GET", "/munbot/v1/sessions", &sess))
+	check.Len(sess, 0)
+
+	check.Equal(http.StatusNotImplemented, do("POST", "/munbot/v1/reload", e))
+	check.Equal("reload: not implemented", e.Msg)
+	check.Equal(http.StatusMethodNotAllowed, do("GET", "/munbot/v1/stop", nil))
+	check.Equal(http.StatusNotFound, do("GET", "/munbot/v1/nothing", nil))
+
+	act := &ActionInfo{}
+	check.Equal(http.StatusAccepted, do("POST", "/munbot/v1/stop", act))
+	check.Equal(&ActionInfo{Action: "stop", Status: "accepted"}, act)
+	rt.stop = errors.New("testing")
+	check.Equal(http.StatusConflict, do("POST", "/munbot/v1/stop", e))
+	check.Equal("stop: testing", e.Msg)
+}
+
+func TestMunbotNoRuntime(t *testing.T) {
+	check := require.New(t)
+	a := newTestApi(nil)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest("POST", "/munbot/v1/stop", nil))
+	check.Equal(http.StatusServiceUnavailable, w.Code)
+	w = httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest("GET", "/api/robots", nil))
+	check.Equal(http.StatusOK, w.Code, "c3pio routes")
+}
@@ -0,0 +1,235 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package wapp
+
+// openapiDoc describes the munbot api. Paths are relative to the server url,
+// which is the api path plus /munbot/v1.
+const openapiDoc string = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Munbot master API",
+    "version": "1"
+  },
+  "servers": [
+    {"url": "/munbot/v1"}
+  ],
+  "security": [
+    {"bearer": []}
+  ],
+  "paths": {
+    "/openapi.json": {
+      "get": {
+        "summary": "This document.",
+        "responses": {
+          "200": {"description": "OpenAPI document."}
+        }
+      }
+    },
+    "/version": {
+      "get": {
+        "summary": "Version and build info.",
+        "responses": {
+          "200": {
+            "description": "Version info.",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Version"}}}
+          }
+        }
+      }
+    },
+    "/status": {
+      "get": {
+        "summary": "Core state, uptime, services status and state changes history.",
+        "responses": {
+          "200": {
+            "description": "Core status.",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}
+          },
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/config": {
+      "get": {
+        "summary": "Effective configuration, with secrets redacted.",
+        "responses": {
+          "200": {
+            "description": "Effective env and config settings.",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Config"}}}
+          },
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/config/explain": {
+      "get": {
+        "summary": "Where each setting value comes from, with secrets redacted.",
+        "parameters": [
+          {"name": "name", "in": "query", "description": "Setting name or name prefix.", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Settings values, sources and overridden values.",
+            "content": {"application/json": {"schema": {
+              "type": "array",
+              "items": {"$ref": "#/components/schemas/Explain"}
+            }}}
+          },
+          "404": {"$ref": "#/components/responses/Error"},
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/sessions": {
+      "get": {
+        "summary": "Live console sessions.",
+        "responses": {
+          "200": {
+            "description": "Sessions list.",
+            "content": {"application/json": {"schema": {
+              "type": "array",
+              "items": {"$ref": "#/components/schemas/Session"}
+            }}}
+          },
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/reload": {
+      "post": {
+        "summary": "Reload the configuration. Requires admin role.",
+        "responses": {
+          "202": {"$ref": "#/components/responses/Action"},
+          "409": {"$ref": "#/components/responses/Error"},
+          "501": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/stop": {
+      "post": {
+        "summary": "Stop the master. Requires admin role.",
+        "responses": {
+          "202": {"$ref": "#/components/responses/Action"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearer": {"type": "http", "scheme": "bearer"}
+    },
+    "responses": {
+      "Action": {
+        "description": "Action accepted.",
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Action"}}}
+      },
+      "Error": {
+        "description": "Error.",
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}
+      }
+    },
+    "schemas": {
+      "Version": {
+        "type": "object",
+        "properties": {
+          "version": {"type": "string"},
+          "major": {"type": "integer"},
+          "minor": {"type": "integer"},
+          "patch": {"type": "integer"},
+          "build": {
+            "type": "object",
+            "properties": {
+              "date": {"type": "string"},
+              "os": {"type": "string"},
+              "arch": {"type": "string"},
+              "tags": {"type": "array", "items": {"type": "string"}}
+            }
+          }
+        }
+      },
+      "Status": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "state": {"type": "string", "enum": ["Dead", "Init", "Run", "Halt"]},
+          "profile": {"type": "string"},
+          "uptime": {"type": "string"},
+          "uptime_seconds": {"type": "number"},
+          "services": {"type": "array", "items": {"$ref": "#/components/schemas/Service"}},
+          "history": {"type": "array", "items": {"$ref": "#/components/schemas/Transition"}}
+        }
+      },
+      "Transition": {
+        "type": "object",
+        "properties": {
+          "from": {"type": "string"},
+          "to": {"type": "string"},
+          "time": {"type": "string", "format": "date-time"},
+          "cause": {"type": "string"}
+        }
+      },
+      "Service": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "status": {"type": "string", "enum": ["starting", "running", "backing-off", "failed", "stopped"]},
+          "restarts": {"type": "integer"},
+          "since": {"type": "string", "format": "date-time"},
+          "error": {"type": "string"}
+        }
+      },
+      "Config": {
+        "type": "object",
+        "properties": {
+          "profile": {"type": "string"},
+          "env": {"type": "object", "additionalProperties": {"type": "string"}},
+          "config": {"type": "object", "additionalProperties": {"type": "string"}}
+        }
+      },
+      "Explain": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "value": {"type": "string"},
+          "source": {"type": "string"},
+          "overrides": {"type": "array", "items": {"$ref": "#/components/schemas/Layer"}}
+        }
+      },
+      "Layer": {
+        "type": "object",
+        "properties": {
+          "source": {"type": "string"},
+          "value": {"type": "string"}
+        }
+      },
+      "Session": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "fingerprint": {"type": "string"},
+          "role": {"type": "string"},
+          "remote": {"type": "string"},
+          "start": {"type": "string", "format": "date-time"},
+          "last_active": {"type": "string", "format": "date-time"},
+          "bytes_in": {"type": "integer"},
+          "bytes_out": {"type": "integer"}
+        }
+      },
+      "Action": {
+        "type": "object",
+        "properties": {
+          "action": {"type": "string"},
+          "status": {"type": "string"}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "properties": {
+          "error": {"type": "string"}
+        }
+      }
+    }
+  }
+}
+`
@@ -18,6 +18,8 @@ type Config struct {
 	Enable bool
 	Debug  bool
 	Path   string
+	// Runtime provides the data for the munbot api.
+	Runtime Runtime
 }
 
 type Api interface {
@@ -64,6 +66,7 @@ func (a *wapp) Configure(c *Config) {
 		a.cpppio.Debug()
 	}
 	log.Debugf("api path: %s", c.Path)
+	a.mount(path.Join(c.Path, "munbot", "v1"), newMunbot(c.Runtime))
 	a.mount(c.Path, a.cpppio)
 }
 
@@ -0,0 +1,90 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+// Audit events.
+const (
+	AuditAccept string = "accept"
+	AuditReject string = "reject"
+	AuditBan    string = "ban"
+	AuditLogin  string = "login"
+	AuditLogout string = "logout"
+	AuditKick   string = "kick"
+	AuditToken  string = "token"
+)
+
+// AuditEvent is an auth decision, as saved in the audit log.
+type AuditEvent struct {
+	Time        time.Time `json:"time"`
+	Event       string    `json:"event"`
+	Remote      string    `json:"remote,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Session     string    `json:"session,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// audit appends the event to the audit log. Errors are logged only, so auth
+// decisions are not affected by them.
+func (a *Auth) audit(ev *AuditEvent) {
+	if a.auditlog == "" {
+		return
+	}
+	ev.Time = time.Now()
+	blob, err := json.Marshal(ev)
+	if err != nil {
+		log.Errorf("Auth audit: %v", err)
+		return
+	}
+	a.auditmu.Lock()
+	defer a.auditmu.Unlock()
+	if err := a.appendFile(a.auditlog, string(blob)+"\n"); err != nil {
+		log.Errorf("Auth audit: %v", err)
+	}
+}
+
+// Audit returns the last n events from the audit log, or all of them if n is
+// not greater than zero.
+func (a *Auth) Audit(n int) ([]*AuditEvent, error) {
+	a.auditmu.Lock()
+	blob, err := vfs.ReadFile(a.auditlog)
+	a.auditmu.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*AuditEvent{}, nil
+		}
+		return nil, err
+	}
+	l := make([]*AuditEvent, 0)
+	sc := bufio.NewScanner(bytes.NewReader(blob))
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		ev := new(AuditEvent)
+		if err := json.Unmarshal(line, ev); err != nil {
+			return nil, log.Errorf("%s: %v", a.auditlog, err)
+		}
+		l = append(l, ev)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if n > 0 && len(l) > n {
+		l = l[len(l)-n:]
+	}
+	return l, nil
+}
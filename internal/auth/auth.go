@@ -9,36 +9,73 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 
 	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/metrics"
 	"github.com/munbot/master/log"
 	"github.com/munbot/master/vfs"
 )
 
 var ErrCADir error = errors.New("auth: invalid CA dir")
 
+var authFailures = metrics.NewCounter("munbot_auth_failures_total",
+	"Authentication failures by method.", "method")
+
+func init() {
+	metrics.Register(authFailures)
+}
+
 // Auth implemenst the console auth manager.
 type Auth struct {
 	enable   bool
 	name     string
 	dir      string
 	priv     string
+	privNext string
+	known    string
+	rotate   string
 	keys     string
+	cakeys   string
+	revoked  string
 	id       ssh.Signer
-	auth     map[string]bool
+	idNext   ssh.Signer
+	idOld    ssh.Signer
+	auth     map[string]*authKey
+	ca       *caFiles
+	sess     *sessions
+	guard    *guard
+	maxTries int
+	auditlog string
+	auditmu  *sync.Mutex
+	tokfn    string
+	tokens   map[string]*apiToken
+	tokHash  string
+	cacert   string
 	rw       *sync.RWMutex
 	lastHash string
+	// rotateOld holds the time until the old host key is trusted
+	rotateOld string
+	// mu guards the settings and host keys, as Configure can be called again
+	// while the console is serving
+	mu *sync.RWMutex
 }
 
 // New creates a new Auth instance.
 func New() *Auth {
 	return &Auth{
-		enable: env.GetBool("MBAUTH"),
-		name:   "master",
-		auth:   map[string]bool{},
-		rw:     new(sync.RWMutex),
+		enable:  env.GetBool("MBAUTH"),
+		name:    "master",
+		auth:    map[string]*authKey{},
+		ca:      newCAFiles(),
+		sess:    newSessions(),
+		guard:   newGuard(),
+		auditmu: new(sync.Mutex),
+		tokens:  map[string]*apiToken{},
+		rw:      new(sync.RWMutex),
+		mu:      new(sync.RWMutex),
 	}
 }
 
@@ -48,13 +85,31 @@ func (a *Auth) keyfp(pk ssh.PublicKey) string {
 
 func (a *Auth) setup() error {
 	log.Debug("setup")
+	a.sess.setup()
+	a.guard.setup()
+	if err := a.setupKeys(); err != nil {
+		return err
+	}
+	if err := a.parseAuthKeys(); err != nil {
+		return err
+	}
+	if err := a.loadTokens(); err != nil {
+		return err
+	}
+	return a.loadCA()
+}
+
+// setupKeys loads the settings and host keys, holding a.mu.
+func (a *Auth) setupKeys() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enable = env.GetBool("MBAUTH")
 	a.name = env.Get("MUNBOT")
+	a.maxTries = int(env.GetUint("MBAUTH_MAX_TRIES"))
 	var err error
 	if err = vfs.MkdirAll(a.dir); err != nil {
 		return log.Error(err)
 	}
-	a.priv = filepath.Join(a.dir, "id_ed25519")
-	a.keys = filepath.Join(a.dir, "authorized_keys")
 	if vfs.Exist(a.priv) {
 		a.id, err = a.sshLoadKeys(a.priv)
 	} else {
@@ -63,13 +118,40 @@ func (a *Auth) setup() error {
 	if err != nil {
 		return err
 	}
-	if a.id != nil {
-		log.Printf("Auth %s %s", a.name, a.keyfp(a.id.PublicKey()))
-		if err := a.parseAuthKeys(); err != nil {
-			return err
-		}
+	if err := a.loadRotation(); err != nil {
+		return err
 	}
-	return nil
+	log.Printf("Auth %s %s", a.name, a.keyfp(a.id.PublicKey()))
+	return a.publishKeys()
+}
+
+// setPaths sets the CA dir files paths.
+func (a *Auth) setPaths() {
+	a.priv = filepath.Join(a.dir, "id_ed25519")
+	a.keys = filepath.Join(a.dir, "authorized_keys")
+	a.cakeys = filepath.Join(a.dir, "ca_keys")
+	a.revoked = filepath.Join(a.dir, "revoked_serials")
+	a.privNext = a.priv + ".next"
+	a.known = filepath.Join(a.dir, "known_hosts")
+	a.rotate = filepath.Join(a.dir, "rotate_after")
+	a.rotateOld = filepath.Join(a.dir, "rotate_old_until")
+	a.auditlog = filepath.Join(a.dir, "audit.log")
+	a.tokfn = filepath.Join(a.dir, "api_tokens")
+	a.cacert = filepath.Join(a.dir, "ca.crt")
+}
+
+// robotName returns the robot name, as set by Configure.
+func (a *Auth) robotName() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.name
+}
+
+// enabled returns true if the authentication is enabled.
+func (a *Auth) enabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.enable
 }
 
 func (a *Auth) parseAuthKeys() error {
@@ -113,28 +195,76 @@ func (a *Auth) parseAuthKeys() error {
 		delete(a.auth, fp)
 	}
 	for len(blob) > 0 {
-		key, _, _, rest, err := ssh.ParseAuthorizedKey(blob)
+		key, _, options, rest, err := ssh.ParseAuthorizedKey(blob)
 		if err != nil {
 			return log.Error(err)
 		}
 		blob = rest
 		fp := a.keyfp(key)
-		a.auth[fp] = true
-		log.Printf("Auth key %s", fp)
+		k, err := newAuthKey(fp, options)
+		if err != nil {
+			log.Errorf("Auth key %s: %v", fp, err)
+			continue
+		}
+		a.auth[fp] = k
+		log.Printf("Auth key %s %s", fp, k.role)
 	}
 	return nil
 }
 
+// publicKeyCallback is called by the ssh server for key queries too, before
+// the client proves it holds the private key. So rejections count as failures
+// of the remote address only, and accepted keys are not trusted until the
+// handshake is done, see Login.
 func (a *Auth) publicKeyCallback(c ssh.ConnMetadata, k ssh.PublicKey) (*ssh.Permissions, error) {
+	fp := a.keyfp(k)
+	if cert, ok := k.(*ssh.Certificate); ok {
+		fp = a.keyfp(cert.Key)
+	}
+	remote := c.RemoteAddr()
+	now := time.Now()
+	if until, banned := a.guard.blocked(guardKey(fp), now); banned {
+		authFailures.Inc("publickey")
+		a.audit(&AuditEvent{Event: AuditReject, Remote: remote.String(), Fingerprint: fp,
+			Reason: "banned until " + until.Format(time.RFC3339)})
+		return nil, log.Errorf("Auth key %s banned until %s", fp, until.Format(time.RFC3339))
+	}
+	p, err := a.checkKey(c, k)
+	if err != nil {
+		authFailures.Inc("publickey")
+		a.audit(&AuditEvent{Event: AuditReject, Remote: remote.String(), Fingerprint: fp,
+			Reason: err.Error()})
+		a.fail(guardAddr(remote), remote.String(), fp, now)
+		return nil, err
+	}
+	return p, nil
+}
+
+// fail records a failure for the guard id, auditing the ban if it gets banned.
+func (a *Auth) fail(id, remote, fp string, now time.Time) {
+	if d := a.guard.fail(id, now); d > 0 {
+		log.Warnf("Auth ban %s for %s", id, d)
+		a.audit(&AuditEvent{Event: AuditBan, Remote: remote, Fingerprint: fp,
+			Reason: id + " for " + d.String()})
+	}
+}
+
+func (a *Auth) checkKey(c ssh.ConnMetadata, k ssh.PublicKey) (*ssh.Permissions, error) {
+	if cert, ok := k.(*ssh.Certificate); ok {
+		return a.certCallback(c, cert)
+	}
 	if err := a.parseAuthKeys(); err != nil {
 		return nil, err
 	}
 	a.rw.RLock()
 	defer a.rw.RUnlock()
 	fp := a.keyfp(k)
-	if a.auth[fp] {
+	if key, ok := a.auth[fp]; ok {
+		if !key.allowFrom(c.RemoteAddr()) {
+			return nil, log.Errorf("Auth key %s from %s not allowed", fp, c.RemoteAddr())
+		}
 		log.Debugf("valid key %q", fp)
-		return &ssh.Permissions{Extensions: map[string]string{"pubkey-fp": fp}}, nil
+		return key.permissions(), nil
 	}
 	return nil, log.Errorf("Auth key %s", fp)
 }
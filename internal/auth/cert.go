@@ -0,0 +1,254 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+// ExtCertID is the ssh.Permissions extension set with the certificate key id
+// when authenticating with an user certificate.
+const ExtCertID string = "cert-id"
+
+// CertOptions holds the settings used to sign an user certificate.
+type CertOptions struct {
+	KeyID        string
+	Principals   []string
+	Role         Role
+	Validity     time.Duration
+	Serial       uint64
+	ForceCommand string
+	NoPty        bool
+}
+
+// caFiles tracks the state of the trusted CA keys and revoked serials files.
+type caFiles struct {
+	keys        map[string]bool
+	keysHash    string
+	revoked     map[uint64]bool
+	revokedHash string
+}
+
+func newCAFiles() *caFiles {
+	return &caFiles{keys: map[string]bool{}, revoked: map[uint64]bool{}}
+}
+
+func (a *Auth) loadCA() error {
+	hash, err := vfs.StatHash(a.cakeys)
+	if err != nil && !os.IsNotExist(err) {
+		return log.Error(err)
+	}
+	if hash != a.ca.keysHash {
+		keys := map[string]bool{}
+		if hash != "" {
+			log.Print("Auth load CA keys...")
+			blob, err := vfs.ReadFile(a.cakeys)
+			if err != nil {
+				return log.Error(err)
+			}
+			for len(blob) > 0 {
+				key, _, _, rest, err := ssh.ParseAuthorizedKey(blob)
+				if err != nil {
+					return log.Error(err)
+				}
+				blob = rest
+				fp := a.keyfp(key)
+				keys[fp] = true
+				log.Printf("Auth CA %s", fp)
+			}
+		}
+		a.rw.Lock()
+		a.ca.keys = keys
+		a.ca.keysHash = hash
+		a.rw.Unlock()
+	}
+	hash, err = vfs.StatHash(a.revoked)
+	if err != nil && !os.IsNotExist(err) {
+		return log.Error(err)
+	}
+	if hash != a.ca.revokedHash {
+		revoked := map[uint64]bool{}
+		if hash != "" {
+			log.Print("Auth load revoked serials...")
+			blob, err := vfs.ReadFile(a.revoked)
+			if err != nil {
+				return log.Error(err)
+			}
+			sc := bufio.NewScanner(bytes.NewReader(blob))
+			for sc.Scan() {
+				line := strings.TrimSpace(sc.Text())
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				serial, err := strconv.ParseUint(line, 10, 64)
+				if err != nil {
+					return log.Errorf("%s: %v", a.revoked, err)
+				}
+				revoked[serial] = true
+			}
+		}
+		a.rw.Lock()
+		a.ca.revoked = revoked
+		a.ca.revokedHash = hash
+		a.rw.Unlock()
+	}
+	return nil
+}
+
+// isUserAuthority checks if the key is a trusted CA. Master's own key is
+// always trusted, so it can sign its operators keys. While a host key rotation
+// is in progress the new key is trusted too, and the old one is trusted until
+// its grace period is over.
+func (a *Auth) isUserAuthority(k ssh.PublicKey) bool {
+	fp := a.keyfp(k)
+	a.mu.RLock()
+	ids := []ssh.Signer{a.id, a.idNext, a.idOld}
+	a.mu.RUnlock()
+	for _, id := range ids {
+		if id != nil && fp == a.keyfp(id.PublicKey()) {
+			return true
+		}
+	}
+	return a.ca.keys[fp]
+}
+
+func (a *Auth) isRevoked(cert *ssh.Certificate) bool {
+	return a.ca.revoked[cert.Serial]
+}
+
+func (a *Auth) certCallback(c ssh.ConnMetadata, cert *ssh.Certificate) (*ssh.Permissions, error) {
+	if err := a.loadCA(); err != nil {
+		return nil, err
+	}
+	a.rw.RLock()
+	defer a.rw.RUnlock()
+	fp := a.keyfp(cert.Key)
+	if cert.CertType != ssh.UserCert {
+		return nil, log.Errorf("Auth cert %s: invalid type %d", fp, cert.CertType)
+	}
+	if !a.isUserAuthority(cert.SignatureKey) {
+		return nil, log.Errorf("Auth cert %s: unknown authority %s", fp,
+			a.keyfp(cert.SignatureKey))
+	}
+	if len(cert.ValidPrincipals) == 0 {
+		return nil, log.Errorf("Auth cert %s: no principals", fp)
+	}
+	checker := &ssh.CertChecker{
+		IsRevoked:                a.isRevoked,
+		SupportedCriticalOptions: []string{"force-command"},
+	}
+	if err := checker.CheckCert(a.robotName(), cert); err != nil {
+		return nil, log.Errorf("Auth cert %s: %v", fp, err)
+	}
+	k := &authKey{fp: fp, role: DefaultRole}
+	if r, ok := cert.Extensions[ExtRole]; ok {
+		var err error
+		if k.role, err = ParseRole(r); err != nil {
+			return nil, log.Errorf("Auth cert %s: %v", fp, err)
+		}
+	}
+	k.command = cert.CriticalOptions["force-command"]
+	if _, ok := cert.Extensions["permit-pty"]; !ok {
+		k.noPty = true
+	}
+	log.Debugf("valid cert %q serial %d id %q", fp, cert.Serial, cert.KeyId)
+	p := k.permissions()
+	p.Extensions[ExtCertID] = cert.KeyId
+	// source-address critical option is enforced by the ssh server
+	p.CriticalOptions = cert.CriticalOptions
+	return p, nil
+}
+
+// SignUserKey creates a new user certificate for the public key, signed with
+// master's private key, or with the new one if a rotation is in progress. If
+// no principals are provided, the certificate will be valid for this robot's
+// name only.
+func (a *Auth) SignUserKey(pub ssh.PublicKey, o *CertOptions) (*ssh.Certificate, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.id == nil {
+		return nil, errors.New("auth: no master key to sign with")
+	}
+	principals := o.Principals
+	if len(principals) == 0 {
+		principals = []string{a.name}
+	}
+	serial := o.Serial
+	if serial == 0 {
+		var b [8]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		serial = binary.BigEndian.Uint64(b[:])
+	}
+	role := o.Role
+	if role == RoleNone {
+		role = DefaultRole
+	}
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             pub,
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           o.KeyID,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(now.Add(-1 * time.Minute).Unix()),
+		ValidBefore:     ssh.CertTimeInfinity,
+		Permissions: ssh.Permissions{
+			CriticalOptions: map[string]string{},
+			Extensions:      map[string]string{ExtRole: role.String()},
+		},
+	}
+	if o.Validity > 0 {
+		cert.ValidBefore = uint64(now.Add(o.Validity).Unix())
+	}
+	if o.ForceCommand != "" {
+		cert.CriticalOptions["force-command"] = o.ForceCommand
+	}
+	if !o.NoPty {
+		cert.Extensions["permit-pty"] = ""
+	}
+	signer := a.id
+	if a.idNext != nil {
+		signer = a.idNext
+	}
+	if err := cert.SignCert(rand.Reader, signer); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Revoke adds the certificate serial number to the revoked serials list.
+func (a *Auth) Revoke(serial uint64) error {
+	return a.appendFile(a.revoked, fmt.Sprintf("%d\n", serial))
+}
+
+// TrustCA adds the public key to the trusted CA keys list.
+func (a *Auth) TrustCA(pub ssh.PublicKey) error {
+	return a.appendFile(a.cakeys, string(ssh.MarshalAuthorizedKey(pub)))
+}
+
+func (a *Auth) appendFile(name, content string) error {
+	fh, err := vfs.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	_, err = fh.WriteString(content)
+	return err
+}
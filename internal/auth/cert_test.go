@@ -0,0 +1,113 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/testing/require"
+)
+
+type testConn struct {
+	ssh.ConnMetadata
+	addr net.Addr
+}
+
+func (c *testConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func newTestConn() ssh.ConnMetadata {
+	return &testConn{addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}}
+}
+
+func newTestAuth(t *testing.T) (*Auth, func()) {
+	dir, err := ioutil.TempDir("", "munbot_test_auth_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := New()
+	if err := a.Configure(dir); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return a, func() { os.RemoveAll(dir) }
+}
+
+func newTestKey(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestCertCallback(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	user := newTestKey(t).PublicKey()
+
+	cert, err := a.SignUserKey(user, &CertOptions{KeyID: "testing", Role: Operator,
+		Validity: time.Hour, Serial: 42})
+	check.NoError(err)
+	check.Equal([]string{a.name}, cert.ValidPrincipals)
+
+	p, err := a.publicKeyCallback(newTestConn(), cert)
+	check.NoError(err)
+	check.Equal(ssh.FingerprintSHA256(user), p.Extensions[ExtFingerprint])
+	check.Equal("operator", p.Extensions[ExtRole])
+	check.Equal("testing", p.Extensions[ExtCertID])
+	_, noPty := p.Extensions[ExtNoPty]
+	check.False(noPty)
+
+	// principals
+	other, err := a.SignUserKey(user, &CertOptions{Principals: []string{"other"}})
+	check.NoError(err)
+	_, err = a.publicKeyCallback(newTestConn(), other)
+	check.Error(err, "principal")
+
+	// revoked
+	check.NoError(a.Revoke(42))
+	_, err = a.publicKeyCallback(newTestConn(), cert)
+	check.Error(err, "revoked")
+}
+
+func TestCertAuthority(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	ca := newTestKey(t)
+	user := newTestKey(t).PublicKey()
+
+	cert := &ssh.Certificate{
+		Key:             user,
+		Serial:          1,
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{a.name},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	check.NoError(cert.SignCert(rand.Reader, ca))
+	_, err := a.publicKeyCallback(newTestConn(), cert)
+	check.Error(err, "unknown authority")
+
+	check.NoError(a.TrustCA(ca.PublicKey()))
+	p, err := a.publicKeyCallback(newTestConn(), cert)
+	check.NoError(err)
+	check.Equal(DefaultRole.String(), p.Extensions[ExtRole])
+	_, noPty := p.Extensions[ExtNoPty]
+	check.True(noPty, "no permit-pty extension")
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/munbot/master/env"
+)
+
+var ErrBanned error = errors.New("auth: temporarily banned")
+
+// maxBanTime caps the exponential growth of ban times. Failures records not
+// updated for longer than this are forgotten.
+var maxBanTime time.Duration = 24 * time.Hour
+
+// failures tracks the authentication failures of a source IP or key.
+type failures struct {
+	count uint
+	bans  uint
+	last  time.Time
+	until time.Time
+}
+
+// guard implements the brute-force protection. After consecutive failures the
+// source has to wait an exponential backoff time before trying again, and after
+// too many of them it is banned for an exponential ban time. The first failure
+// is for free.
+type guard struct {
+	mu      *sync.Mutex
+	db      map[string]*failures
+	tries   uint
+	banTime time.Duration
+}
+
+func newGuard() *guard {
+	return &guard{mu: new(sync.Mutex), db: map[string]*failures{}}
+}
+
+// setup reads the guard settings from env. Zero tries disables the guard.
+func (g *guard) setup() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tries = env.GetUint("MBAUTH_BAN_TRIES")
+	g.banTime = env.GetDuration("MBAUTH_BAN_TIME")
+}
+
+func guardAddr(addr net.Addr) string {
+	return guardRemote(addr.String())
+}
+
+func guardRemote(remote string) string {
+	host := remote
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return "addr:" + host
+}
+
+func guardKey(fp string) string {
+	return "key:" + fp
+}
+
+// blocked returns the time until the source is blocked, if it is.
+func (g *guard) blocked(id string, now time.Time) (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	f, found := g.db[id]
+	if !found || g.tries == 0 || !now.Before(f.until) {
+		return time.Time{}, false
+	}
+	return f.until, true
+}
+
+// fail records a failure for the source. If it gets banned the ban time is
+// returned, otherwise zero.
+func (g *guard) fail(id string, now time.Time) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.tries == 0 {
+		return 0
+	}
+	g.prune(now)
+	f, found := g.db[id]
+	if !found {
+		f = &failures{}
+		g.db[id] = f
+	}
+	f.count++
+	f.last = now
+	if f.count >= g.tries {
+		d := g.backoff(g.banTime, f.bans, maxBanTime)
+		f.bans++
+		f.count = 0
+		f.until = now.Add(d)
+		return d
+	}
+	if f.count > 1 {
+		f.until = now.Add(g.backoff(time.Second, f.count-2, g.banTime))
+	}
+	return 0
+}
+
+// backoff returns base * 2^n, capped to max.
+func (g *guard) backoff(base time.Duration, n uint, max time.Duration) time.Duration {
+	d := base
+	for i := uint(0); i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// reset forgets source failures.
+func (g *guard) reset(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.db, id)
+}
+
+func (g *guard) prune(now time.Time) {
+	for id, f := range g.db {
+		if now.After(f.until) && now.Sub(f.last) > maxBanTime {
+			delete(g.db, id)
+		}
+	}
+}
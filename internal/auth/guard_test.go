@@ -0,0 +1,155 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func newTestGuard() *guard {
+	g := newGuard()
+	g.tries = 3
+	g.banTime = time.Minute
+	return g
+}
+
+func TestGuardBackoff(t *testing.T) {
+	check := require.New(t)
+	g := newTestGuard()
+	now := time.Now()
+
+	check.Equal(time.Duration(0), g.fail("k0", now))
+	_, blocked := g.blocked("k0", now)
+	check.False(blocked, "first failure")
+
+	check.Equal(time.Duration(0), g.fail("k0", now))
+	until, blocked := g.blocked("k0", now)
+	check.True(blocked, "backoff")
+	check.Equal(now.Add(time.Second), until)
+	_, blocked = g.blocked("k0", until)
+	check.False(blocked, "backoff expired")
+
+	g.reset("k0")
+	_, blocked = g.blocked("k0", now)
+	check.False(blocked, "reset")
+}
+
+func TestGuardBan(t *testing.T) {
+	check := require.New(t)
+	g := newTestGuard()
+	now := time.Now()
+
+	g.fail("k0", now)
+	g.fail("k0", now)
+	check.Equal(time.Minute, g.fail("k0", now), "first ban")
+	until, blocked := g.blocked("k0", now)
+	check.True(blocked)
+	check.Equal(now.Add(time.Minute), until)
+
+	g.fail("k0", now)
+	g.fail("k0", now)
+	check.Equal(2*time.Minute, g.fail("k0", now), "second ban")
+
+	g.db["k0"].bans = 64
+	g.fail("k0", now)
+	g.fail("k0", now)
+	check.Equal(maxBanTime, g.fail("k0", now), "max ban time")
+}
+
+func TestGuardDisabled(t *testing.T) {
+	check := require.New(t)
+	g := newGuard()
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		check.Equal(time.Duration(0), g.fail("k0", now))
+	}
+	_, blocked := g.blocked("k0", now)
+	check.False(blocked)
+}
+
+func TestAuthAllow(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	a.guard.tries = 2
+	a.guard.banTime = time.Minute
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}
+	check.NoError(a.Allow(addr))
+
+	user := newTestKey(t).PublicKey()
+	for i := 0; i < 2; i++ {
+		_, err := a.publicKeyCallback(newTestConn(), user)
+		check.Error(err)
+	}
+	check.Equal(ErrBanned, a.Allow(addr))
+	check.Equal(ErrBanned, a.Allow(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 3333}))
+	check.NoError(a.Allow(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 2222}))
+
+	l, err := a.Audit(0)
+	check.NoError(err)
+	events := make([]string, 0)
+	for _, ev := range l {
+		events = append(events, ev.Event)
+	}
+	check.Equal([]string{AuditReject, AuditReject, AuditBan, AuditReject, AuditReject}, events)
+	_, blocked := a.guard.blocked(guardKey(a.keyfp(user)), time.Now())
+	check.False(blocked, "key failures are not counted before the handshake")
+	check.Equal(a.keyfp(user), l[0].Fingerprint)
+
+	l, err = a.Audit(1)
+	check.NoError(err)
+	check.Len(l, 1)
+	check.Equal(AuditReject, l[0].Event)
+	check.Equal("127.0.0.1:3333", l[0].Remote)
+}
+
+func TestAuthLogin(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	a.guard.tries = 2
+	a.guard.banTime = time.Minute
+	a.sess.maxKey = 1
+	user := newTestKey(t).PublicKey()
+	fp := a.keyfp(user)
+	check.NoError(sshWriteFile(a.keys, ssh.MarshalAuthorizedKey(user), 0600))
+	now := time.Now()
+
+	a.guard.fail(guardRemote("127.0.0.1:2222"), now)
+	_, err := a.publicKeyCallback(newTestConn(), user)
+	check.NoError(err, "query authorized key")
+	_, found := a.guard.db[guardRemote("127.0.0.1:2222")]
+	check.True(found, "address failures are not reset before the handshake")
+	l, err := a.Audit(0)
+	check.NoError(err)
+	check.Len(l, 0, "no accept before the handshake")
+
+	s := newTestSession("s0", fp)
+	check.NoError(a.Login(s))
+	_, found = a.guard.db[guardRemote("127.0.0.1:2222")]
+	check.False(found, "address failures reset on login")
+
+	// rejected logins count as key failures
+	check.Error(a.Login(newTestSession("s1", fp)))
+	check.Error(a.Login(newTestSession("s2", fp)))
+	_, blocked := a.guard.blocked(guardKey(fp), now)
+	check.True(blocked, "key banned")
+	_, err = a.publicKeyCallback(newTestConn(), user)
+	check.Error(err, "banned key")
+
+	l, err = a.Audit(0)
+	check.NoError(err)
+	events := make([]string, 0)
+	for _, ev := range l {
+		events = append(events, ev.Event)
+	}
+	check.Equal([]string{AuditAccept, AuditLogin, AuditAccept, AuditReject,
+		AuditAccept, AuditReject, AuditBan, AuditReject}, events)
+}
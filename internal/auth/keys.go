@@ -0,0 +1,156 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/log"
+)
+
+// ssh.Permissions extensions set for authenticated keys.
+const (
+	ExtFingerprint  string = "pubkey-fp"
+	ExtRole         string = "munbot-role"
+	ExtForceCommand string = "force-command"
+	ExtNoPty        string = "no-pty"
+)
+
+// authKey holds an authorized key settings, parsed from its options field.
+type authKey struct {
+	fp      string
+	role    Role
+	command string
+	from    []string
+	noPty   bool
+}
+
+// ignoredKeyOptions are the OpenSSH authorized_keys restriction options which
+// mean nothing to the console, as it does no forwarding and runs no user rc or
+// environment. Options limiting who can login (expiry-time, principals...) are
+// not ignored, so a key using them is not loaded.
+var ignoredKeyOptions map[string]bool = map[string]bool{
+	"agent-forwarding":    true,
+	"no-agent-forwarding": true,
+	"port-forwarding":     true,
+	"no-port-forwarding":  true,
+	"x11-forwarding":      true,
+	"no-x11-forwarding":   true,
+	"user-rc":             true,
+	"no-user-rc":          true,
+	"environment":         true,
+	"permitopen":          true,
+	"permitlisten":        true,
+	"tunnel":              true,
+	"no-touch-required":   true,
+	"verify-required":     true,
+}
+
+func newAuthKey(fp string, options []string) (*authKey, error) {
+	k := &authKey{fp: fp, role: DefaultRole}
+	for _, opt := range options {
+		if name := strings.ToLower(strings.SplitN(opt, "=", 2)[0]); ignoredKeyOptions[name] {
+			log.Debugf("key %s ignore option %s", fp, name)
+			continue
+		}
+		name, val, err := parseKeyOption(opt)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "command":
+			k.command = val
+		case "from":
+			k.from = strings.Split(val, ",")
+		case "no-pty":
+			k.noPty = true
+		case "pty":
+			k.noPty = false
+		case "restrict":
+			k.noPty = true
+		case "munbot-role":
+			k.role, err = ParseRole(val)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("auth: unsupported key option %q", name)
+		}
+	}
+	return k, nil
+}
+
+// parseKeyOption splits an authorized_keys option in its name and unquoted
+// value, if any.
+func parseKeyOption(opt string) (string, string, error) {
+	i := strings.Index(opt, "=")
+	if i < 0 {
+		return strings.ToLower(opt), "", nil
+	}
+	name := strings.ToLower(opt[:i])
+	val := opt[i+1:]
+	if len(val) < 2 || val[0] != '"' || val[len(val)-1] != '"' {
+		return "", "", fmt.Errorf("auth: invalid key option %s value: %s", name, val)
+	}
+	val = strings.Replace(val[1:len(val)-1], `\"`, `"`, -1)
+	return name, val, nil
+}
+
+// allowFrom checks if the remote address matches the from= patterns list.
+// Patterns can be IP addresses, CIDR networks or wildcards (* and ?) and they
+// can be negated with a ! prefix. A negated match always denies access.
+func (k *authKey) allowFrom(addr net.Addr) bool {
+	if len(k.from) == 0 {
+		return true
+	}
+	host := addr.String()
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	allow := false
+	for _, p := range k.from {
+		p = strings.TrimSpace(p)
+		negate := strings.HasPrefix(p, "!")
+		if negate {
+			p = p[1:]
+		}
+		if matchFrom(p, host, ip) {
+			if negate {
+				return false
+			}
+			allow = true
+		}
+	}
+	return allow
+}
+
+func matchFrom(pattern, host string, ip net.IP) bool {
+	if strings.Contains(pattern, "/") {
+		_, ipnet, err := net.ParseCIDR(pattern)
+		return err == nil && ip != nil && ipnet.Contains(ip)
+	}
+	ok, err := path.Match(pattern, host)
+	return err == nil && ok
+}
+
+// permissions returns the ssh permissions for this key.
+func (k *authKey) permissions() *ssh.Permissions {
+	ext := map[string]string{
+		ExtFingerprint: k.fp,
+		ExtRole:        k.role.String(),
+	}
+	if k.command != "" {
+		ext[ExtForceCommand] = k.command
+	}
+	if k.noPty {
+		ext[ExtNoPty] = ""
+	}
+	return &ssh.Permissions{Extensions: ext}
+}
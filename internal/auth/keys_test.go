@@ -0,0 +1,83 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"net"
+	"testing"
+
+	"github.com/munbot/master/testing/assert"
+)
+
+func TestParseRole(t *testing.T) {
+	check := assert.New(t)
+	for _, r := range []Role{Viewer, Operator, Admin} {
+		x, err := ParseRole(r.String())
+		check.NoError(err)
+		check.Equal(r, x)
+	}
+	_, err := ParseRole("none")
+	check.EqualError(err, `auth: invalid role "none"`)
+	check.True(Admin.Allows(Operator))
+	check.True(Viewer.Allows(Viewer))
+	check.False(Viewer.Allows(Operator))
+	check.False(RoleNone.Allows(RoleNone))
+}
+
+func TestKeyOptions(t *testing.T) {
+	check := assert.New(t)
+	k, err := newAuthKey("fp", nil)
+	check.NoError(err)
+	check.Equal(DefaultRole, k.role)
+
+	k, err = newAuthKey("fp", []string{`munbot-role="viewer"`, `command="status"`,
+		"restrict", `from="10.0.0.*,!10.0.0.5"`})
+	check.NoError(err)
+	check.Equal(Viewer, k.role)
+	check.Equal("status", k.command)
+	check.True(k.noPty)
+	check.Equal([]string{"10.0.0.*", "!10.0.0.5"}, k.from)
+
+	p := k.permissions()
+	check.Equal("fp", p.Extensions[ExtFingerprint])
+	check.Equal("viewer", p.Extensions[ExtRole])
+	check.Equal("status", p.Extensions[ExtForceCommand])
+	_, noPty := p.Extensions[ExtNoPty]
+	check.True(noPty)
+
+	k, err = newAuthKey("fp", []string{"restrict", "pty"})
+	check.NoError(err)
+	check.False(k.noPty)
+
+	_, err = newAuthKey("fp", []string{`munbot-role="root"`})
+	check.EqualError(err, `auth: invalid role "root"`)
+	k, err = newAuthKey("fp", []string{"no-port-forwarding", "no-agent-forwarding",
+		"no-X11-forwarding", `permitopen="localhost:80"`, `environment="A=B"`, `munbot-role="viewer"`})
+	check.NoError(err, "ignored openssh options")
+	check.Equal(Viewer, k.role)
+	_, err = newAuthKey("fp", []string{`expiry-time="20200101"`})
+	check.EqualError(err, `auth: unsupported key option "expiry-time"`)
+	_, err = newAuthKey("fp", []string{`munbot-role=viewer`})
+	check.EqualError(err, `auth: invalid key option munbot-role value: viewer`)
+	_, err = newAuthKey("fp", []string{`munbot-other="x"`})
+	check.EqualError(err, `auth: unsupported key option "munbot-other"`)
+	_, err = newAuthKey("fp", []string{"command=status"})
+	check.EqualError(err, `auth: invalid key option command value: status`)
+}
+
+func TestKeyAllowFrom(t *testing.T) {
+	check := assert.New(t)
+	addr := func(s string) net.Addr {
+		a, err := net.ResolveTCPAddr("tcp", s)
+		check.NoError(err)
+		return a
+	}
+	k := &authKey{}
+	check.True(k.allowFrom(addr("192.168.1.1:22")))
+	k.from = []string{"10.0.0.*", "!10.0.0.5", "192.168.0.0/16"}
+	check.True(k.allowFrom(addr("10.0.0.1:22")))
+	check.False(k.allowFrom(addr("10.0.0.5:22")))
+	check.True(k.allowFrom(addr("192.168.1.1:22")))
+	check.False(k.allowFrom(addr("127.0.0.1:22")))
+}
@@ -5,7 +5,9 @@ package auth
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 
@@ -17,30 +19,50 @@ var _ Manager = &Auth{}
 type Manager interface {
 	Configure(cadir string) error
 	ServerConfig() *ssh.ServerConfig
-	Login(fp, sid string) error
+	PublicKey() ssh.PublicKey
+	Login(s *Session) error
 	Logout(sid string) error
+	Sessions() []*SessionInfo
+	Kick(sid string) error
+	Allow(remote net.Addr) error
+	Audit(n int) ([]*AuditEvent, error)
+	IssueToken(name string, role Role, owner string) (*TokenInfo, string, error)
+	RevokeToken(id string) error
+	Tokens() ([]*TokenInfo, error)
+	CheckToken(token string) (*TokenInfo, error)
+	NewServerCert(certfn, keyfn string, hosts []string) error
+	CACertFile() string
 }
 
-// Configure sets up the CA directory.
+// Configure sets up the CA directory. It can be called again to reload the
+// settings and keys.
 func (a *Auth) Configure(cadir string) error {
-	var err error
-	a.dir, err = filepath.Abs(cadir)
-	if a.dir == "." {
+	dir, err := filepath.Abs(cadir)
+	if dir == "." {
 		return ErrCADir
 	}
 	if err != nil {
 		return err
 	}
-	log.Debugf("CA dir: %s", a.dir)
+	log.Debugf("CA dir: %s", dir)
+	a.mu.Lock()
+	if dir != a.dir {
+		a.dir = dir
+		a.setPaths()
+	}
+	a.mu.Unlock()
 	return a.setup()
 }
 
 // ServerConfig creates a new instance of ssh.ServerConfig based on our settings.
 func (a *Auth) ServerConfig() *ssh.ServerConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	cfg := &ssh.ServerConfig{}
 	cfg.ServerVersion = "SSH-2.0-Munbot"
-	cfg.MaxAuthTries = 3
+	cfg.MaxAuthTries = a.maxTries
 	if a.id == nil {
+		log.Error("auth: no host key, was Configure called?")
 		return cfg
 	}
 	cfg.AddHostKey(a.id)
@@ -53,16 +75,28 @@ func (a *Auth) ServerConfig() *ssh.ServerConfig {
 	return cfg
 }
 
-func (a *Auth) publicKeyDisabled(c ssh.ConnMetadata, k ssh.PublicKey) (*ssh.Permissions, error) {
-	return nil, fmt.Errorf("ssh auth disabled")
+// PublicKey returns master's public key, or nil if not set.
+func (a *Auth) PublicKey() ssh.PublicKey {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.id == nil {
+		return nil
+	}
+	return a.id.PublicKey()
 }
 
-func (a *Auth) Login(fp, sid string) error {
-	log.Infof("Auth login %s %s", fp, sid)
-	return nil
+func (a *Auth) publicKeyDisabled(c ssh.ConnMetadata, k ssh.PublicKey) (*ssh.Permissions, error) {
+	return nil, fmt.Errorf("ssh auth disabled")
 }
 
-func (a *Auth) Logout(sid string) error {
-	log.Infof("Auth logout %s", sid)
+// Allow checks if the remote address is not banned. It should be called before
+// the ssh handshake.
+func (a *Auth) Allow(remote net.Addr) error {
+	if until, banned := a.guard.blocked(guardAddr(remote), time.Now()); banned {
+		log.Debugf("Auth %s banned until %s", remote, until.Format(time.RFC3339))
+		a.audit(&AuditEvent{Event: AuditReject, Remote: remote.String(),
+			Reason: "banned until " + until.Format(time.RFC3339)})
+		return ErrBanned
+	}
 	return nil
 }
@@ -0,0 +1,298 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package mbauth implements auth cmd util.
+package mbauth
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/cmd"
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+func newAuth(flags *config.Flags) (*auth.Auth, error) {
+	if err := flags.Profile.Setup(); err != nil {
+		return nil, err
+	}
+	a := auth.New()
+	if err := a.Configure(flags.Profile.GetPath("auth")); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func readPublicKey(fn string) (ssh.PublicKey, error) {
+	blob, err := vfs.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(blob)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fn, err)
+	}
+	return pub, nil
+}
+
+// Cmd is the main mbauth command. It shows master's CA public key.
+type Cmd struct{}
+
+func New() *Cmd {
+	return &Cmd{}
+}
+
+func (c *Cmd) FlagSet(fs *flag.FlagSet) {}
+
+func (c *Cmd) Command(flags *config.Flags) cmd.Command {
+	return &Main{flags: flags}
+}
+
+type Main struct {
+	flags *config.Flags
+}
+
+func (m *Main) Run(args []string) int {
+	if len(args) > 0 {
+		log.Errorf("invalid arguments: %v", args)
+		return 1
+	}
+	a, err := newAuth(m.flags)
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	pub := a.PublicKey()
+	if pub == nil {
+		log.Error("no master key")
+		return 3
+	}
+	fmt.Printf("%s", ssh.MarshalAuthorizedKey(pub))
+	return 0
+}
+
+// SignCmd signs operators public keys.
+type SignCmd struct {
+	opts       *auth.CertOptions
+	role       string
+	principals string
+	output     string
+}
+
+func NewSign() *SignCmd {
+	return &SignCmd{opts: &auth.CertOptions{}}
+}
+
+func (c *SignCmd) FlagSet(fs *flag.FlagSet) {
+	fs.StringVar(&c.opts.KeyID, "id", "", "certificate key `id`")
+	fs.StringVar(&c.principals, "principals", "", "comma separated `names` (default: master robot name)")
+	fs.StringVar(&c.role, "role", auth.DefaultRole.String(), "user `role`: viewer, operator or admin")
+	fs.DurationVar(&c.opts.Validity, "validity", 0, "certificate validity `duration` (default: forever)")
+	fs.Uint64Var(&c.opts.Serial, "serial", 0, "certificate serial `number` (default: random)")
+	fs.StringVar(&c.opts.ForceCommand, "command", "", "force console `command`")
+	fs.BoolVar(&c.opts.NoPty, "no-pty", false, "do not permit pty allocation")
+	fs.StringVar(&c.output, "o", "", "certificate output `filename` (default: key-cert.pub)")
+}
+
+func (c *SignCmd) Command(flags *config.Flags) cmd.Command {
+	return &Sign{cmd: c, flags: flags}
+}
+
+type Sign struct {
+	cmd   *SignCmd
+	flags *config.Flags
+}
+
+func (s *Sign) Run(args []string) int {
+	if len(args) != 1 {
+		log.Errorf("invalid arguments: %v; check %s sign -help", args, os.Args[0])
+		return 1
+	}
+	var err error
+	opts := s.cmd.opts
+	if opts.Role, err = auth.ParseRole(s.cmd.role); err != nil {
+		log.Error(err)
+		return 1
+	}
+	if s.cmd.principals != "" {
+		opts.Principals = strings.Split(s.cmd.principals, ",")
+	}
+	fn := args[0]
+	pub, err := readPublicKey(fn)
+	if err != nil {
+		log.Error(err)
+		return 4
+	}
+	a, err := newAuth(s.flags)
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	cert, err := a.SignUserKey(pub, opts)
+	if err != nil {
+		log.Error(err)
+		return 5
+	}
+	dst := s.cmd.output
+	if dst == "" {
+		dst = strings.TrimSuffix(fn, ".pub") + "-cert.pub"
+	}
+	if err := ioutil.WriteFile(dst, ssh.MarshalAuthorizedKey(cert), 0644); err != nil {
+		log.Error(err)
+		return 6
+	}
+	valid := "forever"
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		valid = time.Unix(int64(cert.ValidBefore), 0).String()
+	}
+	log.Printf("Signed %s serial %d principals %v role %s valid until %s",
+		dst, cert.Serial, cert.ValidPrincipals, opts.Role, valid)
+	return 0
+}
+
+// RevokeCmd revokes certificates by serial number.
+type RevokeCmd struct{}
+
+func NewRevoke() *RevokeCmd {
+	return &RevokeCmd{}
+}
+
+func (c *RevokeCmd) FlagSet(fs *flag.FlagSet) {}
+
+func (c *RevokeCmd) Command(flags *config.Flags) cmd.Command {
+	return &Revoke{flags: flags}
+}
+
+type Revoke struct {
+	flags *config.Flags
+}
+
+func (r *Revoke) Run(args []string) int {
+	if len(args) < 1 {
+		log.Errorf("invalid arguments: %v; check %s revoke -help", args, os.Args[0])
+		return 1
+	}
+	serials := make([]uint64, 0, len(args))
+	for _, arg := range args {
+		serial, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			log.Errorf("invalid serial %q: %v", arg, err)
+			return 1
+		}
+		serials = append(serials, serial)
+	}
+	a, err := newAuth(r.flags)
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	for _, serial := range serials {
+		if err := a.Revoke(serial); err != nil {
+			log.Error(err)
+			return 5
+		}
+		log.Printf("Revoked serial %d", serial)
+	}
+	return 0
+}
+
+// TrustCmd adds trusted CA public keys.
+type TrustCmd struct{}
+
+func NewTrust() *TrustCmd {
+	return &TrustCmd{}
+}
+
+func (c *TrustCmd) FlagSet(fs *flag.FlagSet) {}
+
+func (c *TrustCmd) Command(flags *config.Flags) cmd.Command {
+	return &Trust{flags: flags}
+}
+
+type Trust struct {
+	flags *config.Flags
+}
+
+func (t *Trust) Run(args []string) int {
+	if len(args) < 1 {
+		log.Errorf("invalid arguments: %v; check %s trust -help", args, os.Args[0])
+		return 1
+	}
+	a, err := newAuth(t.flags)
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	for _, fn := range args {
+		pub, err := readPublicKey(fn)
+		if err != nil {
+			log.Error(err)
+			return 4
+		}
+		if err := a.TrustCA(pub); err != nil {
+			log.Error(err)
+			return 5
+		}
+		log.Printf("Trusted CA %s", ssh.FingerprintSHA256(pub))
+	}
+	return 0
+}
+
+// RotateCmd rotates master's host key.
+type RotateCmd struct {
+	grace time.Duration
+}
+
+func NewRotate() *RotateCmd {
+	return &RotateCmd{}
+}
+
+func (c *RotateCmd) FlagSet(fs *flag.FlagSet) {
+	fs.DurationVar(&c.grace, "grace", 7*24*time.Hour, "keep using current key for `duration`")
+}
+
+func (c *RotateCmd) Command(flags *config.Flags) cmd.Command {
+	return &Rotate{cmd: c, flags: flags}
+}
+
+type Rotate struct {
+	cmd   *RotateCmd
+	flags *config.Flags
+}
+
+func (r *Rotate) Run(args []string) int {
+	if len(args) > 0 {
+		log.Errorf("invalid arguments: %v; check %s rotate -help", args, os.Args[0])
+		return 1
+	}
+	a, err := newAuth(r.flags)
+	if err != nil {
+		log.Error(err)
+		return 2
+	}
+	if err := a.Rotate(r.cmd.grace); err != nil {
+		log.Error(err)
+		return 5
+	}
+	after, err := a.RotateAfter()
+	if err != nil {
+		log.Error(err)
+		return 5
+	}
+	if after.IsZero() {
+		log.Printf("Rotated host key %s", ssh.FingerprintSHA256(a.PublicKey()))
+	} else {
+		log.Printf("Host key rotation scheduled after %s", after.Format(time.RFC3339))
+	}
+	return 0
+}
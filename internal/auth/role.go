@@ -0,0 +1,53 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"fmt"
+)
+
+// Role defines the access level of an authenticated user.
+type Role int
+
+const (
+	RoleNone Role = iota
+	Viewer
+	Operator
+	Admin
+)
+
+// DefaultRole is the role assigned to keys with no munbot-role option, so
+// existing authorized keys keep their full access.
+const DefaultRole Role = Admin
+
+var roleName map[Role]string = map[Role]string{
+	RoleNone: "none",
+	Viewer:   "viewer",
+	Operator: "operator",
+	Admin:    "admin",
+}
+
+// ParseRole returns the named role.
+func ParseRole(name string) (Role, error) {
+	for r, n := range roleName {
+		if r != RoleNone && n == name {
+			return r, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("auth: invalid role %q", name)
+}
+
+// String returns role's name.
+func (r Role) String() string {
+	n, ok := roleName[r]
+	if !ok {
+		return fmt.Sprintf("invalid role: %d", int(r))
+	}
+	return n
+}
+
+// Allows checks if r has at least the required role access level.
+func (r Role) Allows(required Role) bool {
+	return r != RoleNone && r >= required
+}
@@ -0,0 +1,175 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+var ErrRotate error = errors.New("auth: host key rotation already in progress")
+
+// Rotate creates a new host key. The current key keeps being used by the ssh
+// server until the grace period expires, meanwhile both of them are published
+// in the known_hosts file. The new key is put in place at setup time once the
+// grace period is over, and the old one is kept as id_ed25519.old.
+// User certificates signed by the new key are trusted since now, and the ones
+// signed by the old key are still trusted for another grace period after the
+// rotation, so operators have time to get them signed again.
+func (a *Auth) Rotate(grace time.Duration) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if vfs.Exist(a.privNext) {
+		return ErrRotate
+	}
+	if _, err := a.sshNewKeys(a.privNext); err != nil {
+		return err
+	}
+	after := time.Now().Add(grace).Format(time.RFC3339) + "\n" + grace.String() + "\n"
+	if err := sshWriteFile(a.rotate, []byte(after), 0644); err != nil {
+		return log.Error(err)
+	}
+	if err := a.loadRotation(); err != nil {
+		return err
+	}
+	return a.publishKeys()
+}
+
+// RotateAfter returns the time when the pending host key rotation will be done.
+// It returns a zero time if there is no rotation in progress.
+func (a *Auth) RotateAfter() (time.Time, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.idNext == nil {
+		return time.Time{}, nil
+	}
+	after, _, err := a.rotateAfter()
+	return after, err
+}
+
+// rotateAfter reads the rotation time and grace period from the rotate file.
+// The grace period is zero for files written by older versions.
+func (a *Auth) rotateAfter() (time.Time, time.Duration, error) {
+	blob, err := vfs.ReadFile(a.rotate)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, 0, nil
+		}
+		return time.Time{}, 0, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(blob)), "\n")
+	after, err := time.Parse(time.RFC3339, strings.TrimSpace(lines[0]))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var grace time.Duration
+	if len(lines) > 1 {
+		grace, err = time.ParseDuration(strings.TrimSpace(lines[1]))
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+	return after, grace, nil
+}
+
+func (a *Auth) loadRotation() error {
+	a.idNext = nil
+	if err := a.loadOldKey(); err != nil {
+		return err
+	}
+	if !vfs.Exist(a.privNext) {
+		return nil
+	}
+	after, grace, err := a.rotateAfter()
+	if err != nil {
+		return log.Error(err)
+	}
+	if time.Now().Before(after) {
+		a.idNext, err = a.sshLoadKeys(a.privNext)
+		if err != nil {
+			return err
+		}
+		log.Printf("Auth rotate %s after %s", a.keyfp(a.idNext.PublicKey()),
+			after.Format(time.RFC3339))
+		return nil
+	}
+	return a.rotateKeys(grace)
+}
+
+// loadOldKey loads the host key replaced by the last rotation, if its grace
+// period is not over yet.
+func (a *Auth) loadOldKey() error {
+	a.idOld = nil
+	blob, err := vfs.ReadFile(a.rotateOld)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return log.Error(err)
+	}
+	until, err := time.Parse(time.RFC3339, strings.TrimSpace(string(blob)))
+	if err != nil {
+		return log.Errorf("%s: %v", a.rotateOld, err)
+	}
+	if !time.Now().Before(until) {
+		return nil
+	}
+	a.idOld, err = a.sshLoadKeys(a.priv + ".old")
+	if err != nil {
+		return err
+	}
+	log.Printf("Auth trust %s until %s", a.keyfp(a.idOld.PublicKey()),
+		until.Format(time.RFC3339))
+	return nil
+}
+
+func (a *Auth) rotateKeys(grace time.Duration) error {
+	next, err := a.sshLoadKeys(a.privNext)
+	if err != nil {
+		return err
+	}
+	log.Printf("Auth rotate %s -> %s", a.keyfp(a.id.PublicKey()), a.keyfp(next.PublicKey()))
+	if err := vfs.Rename(a.priv, a.priv+".old"); err != nil {
+		return log.Error(err)
+	}
+	if err := vfs.Rename(a.privNext, a.priv); err != nil {
+		return log.Error(err)
+	}
+	for _, fn := range []string{a.privNext + ".pub", a.rotate} {
+		if err := vfs.Remove(fn); err != nil && !os.IsNotExist(err) {
+			return log.Error(err)
+		}
+	}
+	until := time.Now().Add(grace).Format(time.RFC3339) + "\n"
+	if err := sshWriteFile(a.rotateOld, []byte(until), 0644); err != nil {
+		return log.Error(err)
+	}
+	a.id = next
+	a.idNext = nil
+	return a.loadOldKey()
+}
+
+// publishKeys writes the current host public key and a known_hosts file with
+// the robot's name as the host pattern, so it can be used from ssh clients
+// via the HostKeyAlias option. While a rotation is in progress both keys are
+// listed.
+func (a *Auth) publishKeys() error {
+	pub := a.sshAuthorizedKey(a.id.PublicKey()) + "\n"
+	if err := sshWriteFile(a.priv+".pub", []byte(pub), 0644); err != nil {
+		return log.Error(err)
+	}
+	known := a.name + " " + pub
+	if a.idNext != nil {
+		known += a.name + " " + a.sshAuthorizedKey(a.idNext.PublicKey()) + "\n"
+	}
+	if err := sshWriteFile(a.known, []byte(known), 0644); err != nil {
+		return log.Error(err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/log"
+)
+
+var (
+	ErrSessionLimit    error = errors.New("auth: too many sessions")
+	ErrSessionKeyLimit error = errors.New("auth: too many sessions for this key")
+)
+
+// Session is a live console session. Byte counters and last activity time are
+// updated by the console server while the session is active.
+type Session struct {
+	in          uint64
+	out         uint64
+	last        int64
+	ID          string
+	Fingerprint string
+	Role        Role
+	Remote      string
+	Start       time.Time
+	// Close is called to terminate the session when it's kicked out.
+	Close func() error
+}
+
+// NewSession creates a new session.
+func NewSession(sid, remote string, start time.Time) *Session {
+	return &Session{ID: sid, Remote: remote, Start: start, last: start.UnixNano()}
+}
+
+// Received adds n bytes to the session's input counter.
+func (s *Session) Received(n int) {
+	atomic.AddUint64(&s.in, uint64(n))
+	atomic.StoreInt64(&s.last, time.Now().UnixNano())
+}
+
+// Sent adds n bytes to the session's output counter.
+func (s *Session) Sent(n int) {
+	atomic.AddUint64(&s.out, uint64(n))
+	atomic.StoreInt64(&s.last, time.Now().UnixNano())
+}
+
+// SessionInfo is a snapshot of a session's state.
+type SessionInfo struct {
+	ID          string    `json:"id"`
+	Fingerprint string    `json:"fingerprint"`
+	Role        string    `json:"role"`
+	Remote      string    `json:"remote"`
+	Start       time.Time `json:"start"`
+	LastActive  time.Time `json:"last_active"`
+	BytesIn     uint64    `json:"bytes_in"`
+	BytesOut    uint64    `json:"bytes_out"`
+}
+
+// Info returns a snapshot of the session.
+func (s *Session) Info() *SessionInfo {
+	return &SessionInfo{
+		ID:          s.ID,
+		Fingerprint: s.Fingerprint,
+		Role:        s.Role.String(),
+		Remote:      s.Remote,
+		Start:       s.Start,
+		LastActive:  time.Unix(0, atomic.LoadInt64(&s.last)),
+		BytesIn:     atomic.LoadUint64(&s.in),
+		BytesOut:    atomic.LoadUint64(&s.out),
+	}
+}
+
+// sessions is the registry of live sessions.
+type sessions struct {
+	mu     *sync.Mutex
+	db     map[string]*Session
+	max    uint
+	maxKey uint
+}
+
+func newSessions() *sessions {
+	return &sessions{mu: new(sync.Mutex), db: map[string]*Session{}}
+}
+
+// setup reads the sessions limits from env. Zero means no limit.
+func (r *sessions) setup() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.max = env.GetUint("MBAUTH_SESSIONS")
+	r.maxKey = env.GetUint("MBAUTH_KEY_SESSIONS")
+}
+
+func (r *sessions) add(s *Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.db[s.ID]; found {
+		return fmt.Errorf("auth: session %s already exists", s.ID)
+	}
+	if r.max > 0 && uint(len(r.db)) >= r.max {
+		return ErrSessionLimit
+	}
+	if r.maxKey > 0 {
+		var n uint
+		for _, x := range r.db {
+			if x.Fingerprint == s.Fingerprint {
+				n++
+			}
+		}
+		if n >= r.maxKey {
+			return ErrSessionKeyLimit
+		}
+	}
+	r.db[s.ID] = s
+	return nil
+}
+
+func (r *sessions) remove(sid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.db[sid]; !found {
+		return false
+	}
+	delete(r.db, sid)
+	return true
+}
+
+func (r *sessions) get(sid string) (*Session, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, found := r.db[sid]
+	return s, found
+}
+
+func (r *sessions) list() []*SessionInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := make([]*SessionInfo, 0, len(r.db))
+	for _, s := range r.db {
+		l = append(l, s.Info())
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Start.Before(l[j].Start) })
+	return l
+}
+
+// Login registers a new session, checking the configured sessions limits. It
+// must be called once the ssh handshake is done, so the session key was proven
+// to be held by the client: its remote address and key failures are reset, or
+// a key failure is recorded if the login is rejected.
+func (a *Auth) Login(s *Session) error {
+	now := time.Now()
+	a.audit(&AuditEvent{Event: AuditAccept, Remote: s.Remote, Fingerprint: s.Fingerprint,
+		Session: s.ID, Role: s.Role.String()})
+	a.guard.reset(guardRemote(s.Remote))
+	if err := a.sess.add(s); err != nil {
+		log.Errorf("Auth login %s %s: %v", s.Fingerprint, s.ID, err)
+		a.audit(&AuditEvent{Event: AuditReject, Remote: s.Remote, Fingerprint: s.Fingerprint,
+			Session: s.ID, Role: s.Role.String(), Reason: err.Error()})
+		a.fail(guardKey(s.Fingerprint), s.Remote, s.Fingerprint, now)
+		return err
+	}
+	a.guard.reset(guardKey(s.Fingerprint))
+	log.Infof("Auth login %s %s %s", s.Fingerprint, s.ID, s.Remote)
+	a.audit(&AuditEvent{Event: AuditLogin, Remote: s.Remote, Fingerprint: s.Fingerprint,
+		Session: s.ID, Role: s.Role.String()})
+	return nil
+}
+
+// Logout removes the session from the registry.
+func (a *Auth) Logout(sid string) error {
+	s, found := a.sess.get(sid)
+	if !found || !a.sess.remove(sid) {
+		return fmt.Errorf("auth: session %s not found", sid)
+	}
+	log.Infof("Auth logout %s", sid)
+	a.audit(&AuditEvent{Event: AuditLogout, Remote: s.Remote, Fingerprint: s.Fingerprint,
+		Session: sid})
+	return nil
+}
+
+// Sessions returns a snapshot of the live sessions, sorted by start time.
+func (a *Auth) Sessions() []*SessionInfo {
+	return a.sess.list()
+}
+
+// Kick terminates the session.
+func (a *Auth) Kick(sid string) error {
+	s, found := a.sess.get(sid)
+	if !found {
+		return fmt.Errorf("auth: session %s not found", sid)
+	}
+	log.Infof("Auth kick %s %s", s.Fingerprint, sid)
+	a.audit(&AuditEvent{Event: AuditKick, Remote: s.Remote, Fingerprint: s.Fingerprint,
+		Session: sid})
+	if s.Close == nil {
+		return fmt.Errorf("auth: session %s can not be closed", sid)
+	}
+	return s.Close()
+}
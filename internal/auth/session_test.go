@@ -0,0 +1,74 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func newTestSession(sid, fp string) *Session {
+	s := NewSession(sid, "127.0.0.1:2222", time.Now())
+	s.Fingerprint = fp
+	s.Role = Viewer
+	return s
+}
+
+func TestSessions(t *testing.T) {
+	check := require.New(t)
+	a := New()
+
+	s := newTestSession("s0", "k0")
+	check.NoError(a.Login(s))
+	check.Error(a.Login(s), "duplicate session")
+	s.Received(10)
+	s.Sent(20)
+
+	l := a.Sessions()
+	check.Len(l, 1)
+	check.Equal("s0", l[0].ID)
+	check.Equal("k0", l[0].Fingerprint)
+	check.Equal("viewer", l[0].Role)
+	check.Equal(uint64(10), l[0].BytesIn)
+	check.Equal(uint64(20), l[0].BytesOut)
+	check.False(l[0].LastActive.Before(l[0].Start))
+
+	check.NoError(a.Logout("s0"))
+	check.Error(a.Logout("s0"), "session not found")
+	check.Len(a.Sessions(), 0)
+}
+
+func TestSessionsLimits(t *testing.T) {
+	check := require.New(t)
+	a := New()
+	a.sess.max = 3
+	a.sess.maxKey = 2
+
+	check.NoError(a.Login(newTestSession("s0", "k0")))
+	check.NoError(a.Login(newTestSession("s1", "k0")))
+	check.Equal(ErrSessionKeyLimit, a.Login(newTestSession("s2", "k0")))
+	check.NoError(a.Login(newTestSession("s3", "k1")))
+	check.Equal(ErrSessionLimit, a.Login(newTestSession("s4", "k2")))
+
+	check.NoError(a.Logout("s0"))
+	check.NoError(a.Login(newTestSession("s5", "k0")))
+}
+
+func TestSessionsKick(t *testing.T) {
+	check := require.New(t)
+	a := New()
+	check.Error(a.Kick("s0"), "session not found")
+
+	closed := false
+	s := newTestSession("s0", "k0")
+	s.Close = func() error {
+		closed = true
+		return nil
+	}
+	check.NoError(a.Login(s))
+	check.NoError(a.Kick("s0"))
+	check.True(closed, "session closed")
+}
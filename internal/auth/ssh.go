@@ -4,9 +4,13 @@
 package auth
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"strings"
 
 	"golang.org/x/crypto/ssh"
 
@@ -14,6 +18,8 @@ import (
 	"github.com/munbot/master/vfs"
 )
 
+const sshKeyMagic string = "openssh-key-v1\x00"
+
 func (a *Auth) sshLoadKeys(fn string) (ssh.Signer, error) {
 	log.Debugf("load keys: %s", fn)
 	var pk ssh.Signer
@@ -33,24 +39,85 @@ func (a *Auth) sshLoadKeys(fn string) (ssh.Signer, error) {
 	return pk, nil
 }
 
-func (a *Auth) sshKeygen(filename string) error {
-	cmd := exec.Command("ssh-keygen", "-v", "-N", "", "-h", "-t", "ed25519",
-		"-f", filename)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	log.Debug(cmd.String())
-	return cmd.Run()
+// sshMarshalKey encodes an ed25519 private key using the unencrypted OpenSSH
+// private key format, same as ssh-keygen does.
+func sshMarshalKey(key ed25519.PrivateKey, comment string) ([]byte, error) {
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+	ci := binary.BigEndian.Uint32(check[:])
+	priv := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+		Pub     []byte
+		Priv    []byte
+		Comment string
+	}{ci, ci, ssh.KeyAlgoED25519, []byte(key.Public().(ed25519.PublicKey)), []byte(key), comment})
+	// no cipher so block size is 8
+	for i := 1; len(priv)%8 != 0; i++ {
+		priv = append(priv, byte(i))
+	}
+	blob := ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{"none", "none", "", 1, pub.Marshal(), priv})
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte(sshKeyMagic), blob...),
+	}), nil
+}
+
+// sshWriteFile writes the named file, setting its mode before any content is
+// written to it.
+func sshWriteFile(fn string, blob []byte, mode os.FileMode) error {
+	fh, err := vfs.Create(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if err := vfs.Chmod(fn, mode); err != nil {
+		return err
+	}
+	_, err = fh.Write(blob)
+	return err
+}
+
+// sshAuthorizedKey returns the authorized_keys format line for the public key,
+// with the robot's name as the comment.
+func (a *Auth) sshAuthorizedKey(pub ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))) + " " + a.name
 }
 
 func (a *Auth) sshNewKeys(fn string) (ssh.Signer, error) {
 	log.Debugf("new keys: %s", fn)
-	if err := a.sshKeygen(fn); err != nil {
-		if err == exec.ErrNotFound {
-			log.Warn(err)
-			return nil, nil
-		} else {
-			return nil, log.Error(err)
-		}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, log.Error(err)
+	}
+	blob, err := sshMarshalKey(key, a.name)
+	if err != nil {
+		return nil, log.Error(err)
+	}
+	if err := sshWriteFile(fn, blob, 0600); err != nil {
+		return nil, log.Error(err)
 	}
-	return a.sshLoadKeys(fn)
+	pk, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, log.Error(err)
+	}
+	pub := a.sshAuthorizedKey(pk.PublicKey()) + "\n"
+	if err := sshWriteFile(fn+".pub", []byte(pub), 0644); err != nil {
+		return nil, log.Error(err)
+	}
+	return pk, nil
 }
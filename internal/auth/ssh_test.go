@@ -0,0 +1,125 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/testing/require"
+	"github.com/munbot/master/vfs"
+)
+
+func TestNewKeys(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	check.NotNil(a.id, "host key")
+
+	fi, err := os.Stat(a.priv)
+	check.NoError(err)
+	check.Equal(os.FileMode(0600), fi.Mode().Perm(), "private key mode")
+
+	k, err := a.sshLoadKeys(a.priv)
+	check.NoError(err)
+	check.Equal(a.keyfp(a.id.PublicKey()), a.keyfp(k.PublicKey()))
+
+	blob, err := vfs.ReadFile(a.priv + ".pub")
+	check.NoError(err)
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey(blob)
+	check.NoError(err)
+	check.Equal(a.name, comment)
+	check.Equal(a.keyfp(a.id.PublicKey()), a.keyfp(pub))
+
+	blob, err = vfs.ReadFile(a.known)
+	check.NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(blob)), "\n")
+	check.Len(lines, 1)
+	check.True(strings.HasPrefix(lines[0], a.name+" ssh-ed25519 "), lines[0])
+}
+
+func TestRotate(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	old := a.keyfp(a.id.PublicKey())
+	user := newTestKey(t).PublicKey()
+	oldCert, err := a.SignUserKey(user, &CertOptions{})
+	check.NoError(err)
+
+	check.NoError(a.Rotate(time.Hour))
+	check.Equal(old, a.keyfp(a.id.PublicKey()), "grace period host key")
+	check.NotNil(a.idNext)
+	next := a.keyfp(a.idNext.PublicKey())
+	check.Equal(ErrRotate, a.Rotate(time.Hour))
+	after, err := a.RotateAfter()
+	check.NoError(err)
+	check.False(after.IsZero())
+
+	blob, err := vfs.ReadFile(a.known)
+	check.NoError(err)
+	check.Len(strings.Split(strings.TrimSpace(string(blob)), "\n"), 2)
+
+	newCert, err := a.SignUserKey(user, &CertOptions{})
+	check.NoError(err)
+	check.Equal(next, a.keyfp(newCert.SignatureKey), "signed with the new key")
+	for _, cert := range []*ssh.Certificate{oldCert, newCert} {
+		_, err = a.publicKeyCallback(newTestConn(), cert)
+		check.NoError(err, "grace period certs")
+	}
+
+	// grace period is over
+	check.NoError(sshWriteFile(a.rotate, []byte(time.Now().Format(time.RFC3339)+"\n1h0m0s\n"), 0644))
+	check.NoError(a.setup())
+	check.Nil(a.idNext)
+	check.Equal(next, a.keyfp(a.id.PublicKey()), "rotated host key")
+	check.True(vfs.Exist(a.priv + ".old"))
+	check.False(vfs.Exist(a.privNext))
+
+	blob, err = vfs.ReadFile(a.known)
+	check.NoError(err)
+	check.Len(strings.Split(strings.TrimSpace(string(blob)), "\n"), 1)
+
+	for _, cert := range []*ssh.Certificate{oldCert, newCert} {
+		_, err = a.publicKeyCallback(newTestConn(), cert)
+		check.NoError(err, "old key grace period certs")
+	}
+
+	// old key grace period is over
+	check.NoError(sshWriteFile(a.rotateOld, []byte(time.Now().Format(time.RFC3339)), 0644))
+	check.NoError(a.setup())
+	check.Nil(a.idOld)
+	_, err = a.publicKeyCallback(newTestConn(), oldCert)
+	check.Error(err, "old key cert")
+	_, err = a.publicKeyCallback(newTestConn(), newCert)
+	check.NoError(err, "new key cert")
+}
+
+func TestConfigureReload(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	user := newTestKey(t).PublicKey()
+	cert, err := a.SignUserKey(user, &CertOptions{})
+	check.NoError(err)
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			a.ServerConfig()
+			a.publicKeyCallback(newTestConn(), cert)
+			a.SignUserKey(user, &CertOptions{})
+		}
+	}()
+	// console callbacks run while the settings are reloaded
+	check.NoError(a.Configure(a.dir))
+	check.NoError(a.Rotate(time.Hour))
+	<-done
+	_, err = a.publicKeyCallback(newTestConn(), cert)
+	check.NoError(err)
+}
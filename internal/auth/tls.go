@@ -0,0 +1,157 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+var caValidity time.Duration = 10 * 365 * 24 * time.Hour
+var certValidity time.Duration = 365 * 24 * time.Hour
+
+// identityKey returns master's private key.
+func (a *Auth) identityKey() (crypto.Signer, error) {
+	blob, err := vfs.ReadFile(a.priv)
+	if err != nil {
+		return nil, err
+	}
+	k, err := ssh.ParseRawPrivateKey(blob)
+	if err != nil {
+		return nil, err
+	}
+	switch key := k.(type) {
+	case *ed25519.PrivateKey:
+		return *key, nil
+	case crypto.Signer:
+		return key, nil
+	}
+	return nil, errors.New("auth: invalid identity key type")
+}
+
+func certSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// CACertFile returns the filename of master's CA certificate.
+func (a *Auth) CACertFile() string {
+	return a.cacert
+}
+
+// caCertificate loads master's CA certificate, creating it if it does not
+// exist or if it was not signed by current master's identity, after a host
+// key rotation for example.
+func (a *Auth) caCertificate(key crypto.Signer) (*x509.Certificate, error) {
+	if blob, err := vfs.ReadFile(a.cacert); err == nil {
+		if b, _ := pem.Decode(blob); b != nil {
+			cert, err := x509.ParseCertificate(b.Bytes)
+			if err == nil && cert.CheckSignatureFrom(cert) == nil {
+				if pub, ok := cert.PublicKey.(ed25519.PublicKey); ok &&
+					bytes.Equal(pub, key.Public().(ed25519.PublicKey)) {
+					return cert, nil
+				}
+			}
+		}
+		log.Warnf("Auth %s: invalid CA certificate, creating a new one", a.cacert)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	serial, err := certSerial()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: a.robotName() + " CA", Organization: []string{"munbot"}},
+		NotBefore:             now.Add(-1 * time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	blob := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := sshWriteFile(a.cacert, blob, 0644); err != nil {
+		return nil, err
+	}
+	log.Printf("Auth CA certificate %s", a.cacert)
+	return x509.ParseCertificate(der)
+}
+
+// NewServerCert creates a new TLS key and certificate for the given hosts,
+// signed by master's CA certificate. The CA certificate is self-signed by
+// master's identity key.
+func (a *Auth) NewServerCert(certfn, keyfn string, hosts []string) error {
+	ikey, err := a.identityKey()
+	if err != nil {
+		return log.Error(err)
+	}
+	ca, err := a.caCertificate(ikey)
+	if err != nil {
+		return log.Error(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return log.Error(err)
+	}
+	serial, err := certSerial()
+	if err != nil {
+		return log.Error(err)
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: a.robotName(), Organization: []string{"munbot"}},
+		NotBefore:    now.Add(-1 * time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			if !ip.IsUnspecified() {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			}
+		} else if h != "" {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), ikey)
+	if err != nil {
+		return log.Error(err)
+	}
+	kblob, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return log.Error(err)
+	}
+	if err := sshWriteFile(keyfn, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: kblob}), 0600); err != nil {
+		return log.Error(err)
+	}
+	if err := sshWriteFile(certfn, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return log.Error(err)
+	}
+	log.Printf("Auth server certificate %s %v %v", certfn, tmpl.DNSNames, tmpl.IPAddresses)
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+	"github.com/munbot/master/vfs"
+)
+
+func TestNewServerCert(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	certfn := filepath.Join(a.dir, "api.crt")
+	keyfn := filepath.Join(a.dir, "api.key")
+	check.NoError(a.NewServerCert(certfn, keyfn, []string{"localhost", "127.0.0.1", "0.0.0.0", ""}))
+
+	fi, err := os.Stat(keyfn)
+	check.NoError(err)
+	check.Equal(os.FileMode(0600), fi.Mode().Perm(), "key file mode")
+
+	cert, err := tls.LoadX509KeyPair(certfn, keyfn)
+	check.NoError(err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	check.NoError(err)
+	check.Equal([]string{"localhost"}, leaf.DNSNames)
+	check.Len(leaf.IPAddresses, 1)
+
+	blob, err := vfs.ReadFile(a.CACertFile())
+	check.NoError(err)
+	pool := x509.NewCertPool()
+	check.True(pool.AppendCertsFromPEM(blob))
+	_, err = leaf.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: pool})
+	check.NoError(err, "verify server cert")
+
+	// CA certificate is reused
+	check.NoError(a.NewServerCert(certfn, keyfn, []string{"localhost"}))
+	blob2, err := vfs.ReadFile(a.CACertFile())
+	check.NoError(err)
+	check.Equal(blob, blob2)
+
+	// CA certificate is created again after host key rotation
+	check.NoError(a.Rotate(0))
+	check.NoError(a.NewServerCert(certfn, keyfn, []string{"localhost"}))
+	blob2, err = vfs.ReadFile(a.CACertFile())
+	check.NoError(err)
+	check.NotEqual(blob, blob2)
+}
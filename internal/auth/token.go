@@ -0,0 +1,214 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/vfs"
+)
+
+var ErrToken error = errors.New("auth: invalid token")
+
+// TokenInfo describes an api token. The token secret is never stored, only its
+// hash.
+type TokenInfo struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	Owner   string    `json:"owner,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// apiToken is a token as saved in the api tokens file.
+type apiToken struct {
+	TokenInfo
+	Hash string `json:"hash"`
+}
+
+func tokenHash(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func (a *Auth) loadTokens() error {
+	hash, err := vfs.StatHash(a.tokfn)
+	if err != nil && !os.IsNotExist(err) {
+		return log.Error(err)
+	}
+	if hash == a.tokHash {
+		return nil
+	}
+	db := map[string]*apiToken{}
+	if hash != "" {
+		log.Print("Auth load api tokens...")
+		blob, err := vfs.ReadFile(a.tokfn)
+		if err != nil {
+			return log.Error(err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(blob))
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			t := new(apiToken)
+			if err := json.Unmarshal(line, t); err != nil {
+				return log.Errorf("%s: %v", a.tokfn, err)
+			}
+			db[t.ID] = t
+		}
+	}
+	a.rw.Lock()
+	a.tokens = db
+	a.tokHash = hash
+	a.rw.Unlock()
+	return nil
+}
+
+// saveTokens writes the api tokens file. Caller must hold the write lock.
+func (a *Auth) saveTokens() error {
+	buf := new(bytes.Buffer)
+	for _, t := range a.tokens {
+		blob, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		buf.Write(blob)
+		buf.WriteString("\n")
+	}
+	if err := sshWriteFile(a.tokfn, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	a.tokHash, _ = vfs.StatHash(a.tokfn)
+	return nil
+}
+
+// IssueToken creates a new api token with the given role. The returned token
+// string is the only way to use it, as only its hash is saved.
+func (a *Auth) IssueToken(name string, role Role, owner string) (*TokenInfo, string, error) {
+	if role == RoleNone {
+		return nil, "", fmt.Errorf("auth: invalid role %q", role)
+	}
+	if err := a.loadTokens(); err != nil {
+		return nil, "", err
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, "", err
+	}
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return nil, "", err
+	}
+	t := &apiToken{
+		TokenInfo: TokenInfo{
+			ID:      hex.EncodeToString(id[:]),
+			Name:    name,
+			Role:    role.String(),
+			Owner:   owner,
+			Created: time.Now(),
+		},
+	}
+	s := base64.RawURLEncoding.EncodeToString(secret[:])
+	t.Hash = tokenHash(s)
+	a.rw.Lock()
+	defer a.rw.Unlock()
+	a.tokens[t.ID] = t
+	if err := a.saveTokens(); err != nil {
+		delete(a.tokens, t.ID)
+		return nil, "", log.Error(err)
+	}
+	log.Infof("Auth token %s %s %s", t.ID, t.Role, t.Name)
+	a.audit(&AuditEvent{Event: AuditToken, Fingerprint: owner, Role: t.Role,
+		Reason: fmt.Sprintf("issued %s %q", t.ID, t.Name)})
+	info := t.TokenInfo
+	return &info, t.ID + "." + s, nil
+}
+
+// RevokeToken removes the api token.
+func (a *Auth) RevokeToken(id string) error {
+	if err := a.loadTokens(); err != nil {
+		return err
+	}
+	a.rw.Lock()
+	defer a.rw.Unlock()
+	t, found := a.tokens[id]
+	if !found {
+		return fmt.Errorf("auth: token %s not found", id)
+	}
+	delete(a.tokens, id)
+	if err := a.saveTokens(); err != nil {
+		a.tokens[id] = t
+		return log.Error(err)
+	}
+	log.Infof("Auth token %s revoked", id)
+	a.audit(&AuditEvent{Event: AuditToken, Fingerprint: t.Owner, Role: t.Role,
+		Reason: fmt.Sprintf("revoked %s %q", t.ID, t.Name)})
+	return nil
+}
+
+// Tokens returns the list of api tokens, sorted by creation time.
+func (a *Auth) Tokens() ([]*TokenInfo, error) {
+	if err := a.loadTokens(); err != nil {
+		return nil, err
+	}
+	a.rw.RLock()
+	defer a.rw.RUnlock()
+	l := make([]*TokenInfo, 0, len(a.tokens))
+	for _, t := range a.tokens {
+		info := t.TokenInfo
+		l = append(l, &info)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Created.Before(l[j].Created) })
+	return l, nil
+}
+
+// CheckToken validates the token string, as returned by IssueToken.
+func (a *Auth) CheckToken(token string) (*TokenInfo, error) {
+	info, err := a.checkToken(token)
+	if err == ErrToken {
+		authFailures.Inc("token")
+	}
+	return info, err
+}
+
+func (a *Auth) checkToken(token string) (*TokenInfo, error) {
+	if !a.enabled() {
+		return nil, ErrToken
+	}
+	i := strings.Index(token, ".")
+	if i < 1 {
+		return nil, ErrToken
+	}
+	id, secret := token[:i], token[i+1:]
+	if err := a.loadTokens(); err != nil {
+		return nil, err
+	}
+	a.rw.RLock()
+	defer a.rw.RUnlock()
+	t, found := a.tokens[id]
+	if !found {
+		return nil, ErrToken
+	}
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(tokenHash(secret))) != 1 {
+		return nil, ErrToken
+	}
+	info := t.TokenInfo
+	return &info, nil
+}
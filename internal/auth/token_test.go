@@ -0,0 +1,68 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package auth
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+	"github.com/munbot/master/vfs"
+)
+
+func TestTokens(t *testing.T) {
+	check := require.New(t)
+	a, cleanup := newTestAuth(t)
+	defer cleanup()
+	a.enable = true
+
+	_, _, err := a.IssueToken("testing", RoleNone, "")
+	check.Error(err, "invalid role")
+
+	info, token, err := a.IssueToken("testing", Operator, "SHA256:testing")
+	check.NoError(err)
+	check.Equal("operator", info.Role)
+	check.True(strings.HasPrefix(token, info.ID+"."))
+
+	fi, err := os.Stat(a.tokfn)
+	check.NoError(err)
+	check.Equal(os.FileMode(0600), fi.Mode().Perm(), "tokens file mode")
+	blob, err := vfs.ReadFile(a.tokfn)
+	check.NoError(err)
+	check.NotContains(string(blob), token[len(info.ID)+1:], "token secret saved")
+
+	got, err := a.CheckToken(token)
+	check.NoError(err)
+	check.Equal(info.ID, got.ID)
+	check.Equal("SHA256:testing", got.Owner)
+
+	fails := authFailures.Value("token")
+	_, err = a.CheckToken(token + "x")
+	check.Equal(ErrToken, err)
+	_, err = a.CheckToken("testing")
+	check.Equal(ErrToken, err)
+	check.Equal(fails+2, authFailures.Value("token"), "token failures metric")
+
+	// reload from file
+	b := New()
+	b.tokfn = a.tokfn
+	b.enable = true
+	l, err := b.Tokens()
+	check.NoError(err)
+	check.Len(l, 1)
+	_, err = b.CheckToken(token)
+	check.NoError(err)
+
+	check.NoError(a.RevokeToken(info.ID))
+	check.Error(a.RevokeToken(info.ID), "token not found")
+	_, err = a.CheckToken(token)
+	check.Equal(ErrToken, err)
+
+	a.enable = false
+	_, token, err = a.IssueToken("disabled", Viewer, "")
+	check.NoError(err)
+	_, err = a.CheckToken(token)
+	check.Equal(ErrToken, err, "auth disabled")
+}
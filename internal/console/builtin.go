@@ -0,0 +1,438 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package console
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/internal/event"
+	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/utils/cmdline"
+)
+
+// masterCommands maps the master robot commands to the console command that
+// runs them. Any other one is run by the master command.
+var masterCommands map[string]string = map[string]string{
+	"status": "status",
+	"reload": "reload",
+}
+
+// MasterRole returns the role needed to run the named master robot command,
+// which is the role of the console command that runs it.
+func MasterRole(name string) auth.Role {
+	cmd, found := masterCommands[name]
+	if !found {
+		cmd = "master"
+	}
+	for _, c := range builtins(nil) {
+		if c.Name == cmd {
+			return c.Role
+		}
+	}
+	return auth.Admin
+}
+
+func (s *Console) addBuiltins() {
+	for _, c := range builtins(s) {
+		if err := s.cmds.add(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// builtins returns the console builtin commands. s can be nil if only the
+// commands definitions are needed.
+func builtins(s *Console) []*Command {
+	return []*Command{
+		{"help", "[command]", "show commands help", auth.Viewer, s.cmdHelp},
+		{"exit", "", "close the session", auth.Viewer, s.cmdLogout},
+		{"logout", "", "close the session", auth.Viewer, s.cmdLogout},
+		{"status", "", "show master robot status", auth.Viewer, s.cmdStatus},
+		{"uptime", "", "show master robot uptime", auth.Viewer, s.cmdUptime},
+		{"master", "command [key=value...]", "run a master robot command", auth.Admin, s.cmdMaster},
+		{"reload", "", "reload master configuration", auth.Admin, s.cmdReload},
+		{"robots", "", "list robots", auth.Viewer, s.cmdRobots},
+		{"devices", "robot", "list robot devices", auth.Viewer, s.cmdDevices},
+		{"cmd", "robot device command [key=value...]", "run a device command", auth.Operator, s.cmdDevice},
+		{"watch", "[-n count] [robot [device [event]]]", "show devices events as they happen", auth.Viewer, s.cmdWatch},
+		{"who", "", "list console sessions", auth.Viewer, s.cmdWho},
+		{"kick", "session", "close a console session", auth.Admin, s.cmdKick},
+		{"audit", "[count]", "show auth audit log last events", auth.Admin, s.cmdAudit},
+		{"token", "new name [role] | list | revoke id", "manage api tokens", auth.Viewer, s.cmdToken},
+	}
+}
+
+func (s *Console) gobotMaster() (*gobot.Master, error) {
+	if s.master == nil {
+		return nil, NewCommandError(StatusFail, "master robot not available")
+	}
+	return s.master.Gobot(), nil
+}
+
+func (s *Console) cmdHelp(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 1 {
+		return NewCommandError(StatusUsage, "usage: help [command]")
+	}
+	if len(args) == 1 {
+		c, found := s.cmds.get(args[0])
+		if !found {
+			return NewCommandError(StatusNotFound, "%s: command not found", args[0])
+		}
+		fmt.Fprintf(out, "%s %s\n  %s\n", c.Name, c.Usage, c.Help)
+		return nil
+	}
+	for _, c := range s.cmds.list() {
+		fmt.Fprintf(out, "%-10s %s\n", c.Name, c.Help)
+	}
+	return nil
+}
+
+func (s *Console) cmdLogout(ctx context.Context, out io.Writer, args []string) error {
+	return errLogout
+}
+
+func (s *Console) cmdStatus(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 0 {
+		return NewCommandError(StatusUsage, "usage: status")
+	}
+	return s.runMaster(out, "status", nil)
+}
+
+func (s *Console) cmdUptime(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 0 {
+		return NewCommandError(StatusUsage, "usage: uptime")
+	}
+	if s.master == nil {
+		return NewCommandError(StatusFail, "master robot not available")
+	}
+	fmt.Fprintf(out, "%s\n", s.master.Uptime())
+	return nil
+}
+
+func (s *Console) cmdMaster(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) < 1 {
+		return NewCommandError(StatusUsage, "usage: master command [key=value...]")
+	}
+	params, err := cmdline.Params(args[1:])
+	if err != nil {
+		return NewCommandError(StatusUsage, "master: %s", err)
+	}
+	return s.runMaster(out, args[0], params)
+}
+
+func (s *Console) cmdReload(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 0 {
+		return NewCommandError(StatusUsage, "usage: reload")
+	}
+	return s.runMaster(out, "reload", nil)
+}
+
+func (s *Console) runMaster(out io.Writer, name string, params map[string]interface{}) error {
+	m, err := s.gobotMaster()
+	if err != nil {
+		return err
+	}
+	fn := m.Command(name)
+	if fn == nil {
+		return NewCommandError(StatusNotFound, "master: %s: unknown command", name)
+	}
+	return writeJSON(out, fn(params))
+}
+
+func (s *Console) cmdRobots(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 0 {
+		return NewCommandError(StatusUsage, "usage: robots")
+	}
+	m, err := s.gobotMaster()
+	if err != nil {
+		return err
+	}
+	m.Robots().Each(func(r *gobot.Robot) {
+		fmt.Fprintf(out, "%s running=%v connections=%d devices=%d\n", r.Name,
+			r.Running(), r.Connections().Len(), r.Devices().Len())
+	})
+	return nil
+}
+
+func (s *Console) cmdDevices(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) != 1 {
+		return NewCommandError(StatusUsage, "usage: devices robot")
+	}
+	m, err := s.gobotMaster()
+	if err != nil {
+		return err
+	}
+	r := m.Robot(args[0])
+	if r == nil {
+		return NewCommandError(StatusNotFound, "%s: robot not found", args[0])
+	}
+	r.Devices().Each(func(d gobot.Device) {
+		cmds := make([]string, 0)
+		if c, ok := d.(gobot.Commander); ok {
+			for n := range c.Commands() {
+				cmds = append(cmds, n)
+			}
+			sort.Strings(cmds)
+		}
+		fmt.Fprintf(out, "%s driver=%s commands=%s\n", d.Name(),
+			reflect.TypeOf(d).String(), strings.Join(cmds, ","))
+	})
+	return nil
+}
+
+func (s *Console) cmdDevice(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) < 3 {
+		return NewCommandError(StatusUsage, "usage: cmd robot device command [key=value...]")
+	}
+	m, err := s.gobotMaster()
+	if err != nil {
+		return err
+	}
+	r := m.Robot(args[0])
+	if r == nil {
+		return NewCommandError(StatusNotFound, "%s: robot not found", args[0])
+	}
+	d := r.Device(args[1])
+	if d == nil {
+		return NewCommandError(StatusNotFound, "%s/%s: device not found", args[0], args[1])
+	}
+	c, ok := d.(gobot.Commander)
+	if !ok {
+		return NewCommandError(StatusNotFound, "%s/%s: device has no commands", args[0], args[1])
+	}
+	fn := c.Command(args[2])
+	if fn == nil {
+		return NewCommandError(StatusNotFound, "%s/%s: %s: unknown command", args[0], args[1], args[2])
+	}
+	params, err := cmdline.Params(args[3:])
+	if err != nil {
+		return NewCommandError(StatusUsage, "cmd: %s", err)
+	}
+	master.CommandRun(args[0], args[1], args[2])
+	return writeJSON(out, fn(params))
+}
+
+// cmdWatch writes devices events, as json lines, until count events were sent,
+// the session is closed, or the user types Ctrl-C or q. Filter arguments can be
+// * to match any value.
+func (s *Console) cmdWatch(ctx context.Context, out io.Writer, args []string) error {
+	usage := NewCommandError(StatusUsage, "usage: watch [-n count] [robot [device [event]]]")
+	count := 0
+	if len(args) > 0 && args[0] == "-n" {
+		if len(args) < 2 {
+			return usage
+		}
+		var err error
+		count, err = strconv.Atoi(args[1])
+		if err != nil || count < 1 {
+			return NewCommandError(StatusUsage, "watch: invalid count %q", args[1])
+		}
+		args = args[2:]
+	}
+	if len(args) > 3 {
+		return usage
+	}
+	if s.master == nil {
+		return NewCommandError(StatusFail, "master robot not available")
+	}
+	f := make([]string, 3)
+	for i, a := range args {
+		if a != "*" {
+			f[i] = a
+		}
+	}
+	var input <-chan []byte
+	if in := s.ctxInput(ctx); in != nil {
+		input = in.c
+	}
+	hub := s.master.Events()
+	_, sub := hub.Subscribe(&event.Filter{Robot: f[0], Device: f[1], Event: f[2]}, 0)
+	defer hub.Unsubscribe(sub)
+	for n := 0; count == 0 || n < count; {
+		select {
+		case <-ctx.Done():
+			return nil
+		case b, ok := <-input:
+			if !ok || bytes.ContainsAny(b, "\x03q") {
+				return nil
+			}
+		case ev := <-sub.C:
+			n++
+			if _, err := fmt.Fprintf(out, "%s\n", ev.JSON()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Console) authManager() (auth.Manager, error) {
+	if s.auth == nil {
+		return nil, NewCommandError(StatusFail, "auth manager not available")
+	}
+	return s.auth, nil
+}
+
+func (s *Console) cmdWho(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 0 {
+		return NewCommandError(StatusUsage, "usage: who")
+	}
+	a, err := s.authManager()
+	if err != nil {
+		return err
+	}
+	sid := s.ctxSession(ctx)
+	// viewers can only see their own session
+	own := !s.ctxRole(ctx).Allows(auth.Operator)
+	for _, i := range a.Sessions() {
+		if own && i.ID != sid {
+			continue
+		}
+		mark := " "
+		if i.ID == sid {
+			mark = "*"
+		}
+		fmt.Fprintf(out, "%s%s role=%s remote=%s key=%s since=%s idle=%s in=%d out=%d\n",
+			mark, i.ID, i.Role, i.Remote, i.Fingerprint, i.Start.Format(time.RFC3339),
+			time.Since(i.LastActive).Truncate(time.Second), i.BytesIn, i.BytesOut)
+	}
+	return nil
+}
+
+func (s *Console) cmdKick(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) != 1 {
+		return NewCommandError(StatusUsage, "usage: kick session")
+	}
+	a, err := s.authManager()
+	if err != nil {
+		return err
+	}
+	if err := a.Kick(args[0]); err != nil {
+		return NewCommandError(StatusNotFound, "kick: %s", err)
+	}
+	return nil
+}
+
+func (s *Console) cmdAudit(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) > 1 {
+		return NewCommandError(StatusUsage, "usage: audit [count]")
+	}
+	n := 20
+	if len(args) == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return NewCommandError(StatusUsage, "audit: invalid count %q", args[0])
+		}
+	}
+	a, err := s.authManager()
+	if err != nil {
+		return err
+	}
+	l, err := a.Audit(n)
+	if err != nil {
+		return NewCommandError(StatusFail, "audit: %s", err)
+	}
+	enc := json.NewEncoder(out)
+	for _, ev := range l {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Console) cmdToken(ctx context.Context, out io.Writer, args []string) error {
+	usage := NewCommandError(StatusUsage, "usage: token new name [role] | list | revoke id")
+	if len(args) < 1 {
+		return usage
+	}
+	a, err := s.authManager()
+	if err != nil {
+		return err
+	}
+	role := s.ctxRole(ctx)
+	fp, _ := s.ctxExtension(ctx, auth.ExtFingerprint)
+	// owned returns the tokens the user can manage.
+	owned := func() ([]*auth.TokenInfo, error) {
+		l, err := a.Tokens()
+		if err != nil {
+			return nil, NewCommandError(StatusFail, "token: %s", err)
+		}
+		if role.Allows(auth.Admin) {
+			return l, nil
+		}
+		own := make([]*auth.TokenInfo, 0)
+		for _, t := range l {
+			if t.Owner == fp {
+				own = append(own, t)
+			}
+		}
+		return own, nil
+	}
+	switch args[0] {
+	case "new":
+		if len(args) < 2 || len(args) > 3 {
+			return usage
+		}
+		r := role
+		if len(args) == 3 {
+			if r, err = auth.ParseRole(args[2]); err != nil {
+				return NewCommandError(StatusUsage, "token: %s", err)
+			}
+		}
+		if !role.Allows(r) {
+			return NewCommandError(StatusDenied, "token: %s role not allowed", r)
+		}
+		info, token, err := a.IssueToken(args[1], r, fp)
+		if err != nil {
+			return NewCommandError(StatusFail, "token: %s", err)
+		}
+		fmt.Fprintf(out, "%s role=%s\n%s\n", info.ID, info.Role, token)
+	case "list":
+		if len(args) != 1 {
+			return usage
+		}
+		l, err := owned()
+		if err != nil {
+			return err
+		}
+		for _, t := range l {
+			fmt.Fprintf(out, "%s name=%q role=%s owner=%s created=%s\n", t.ID, t.Name,
+				t.Role, t.Owner, t.Created.Format(time.RFC3339))
+		}
+	case "revoke":
+		if len(args) != 2 {
+			return usage
+		}
+		l, err := owned()
+		if err != nil {
+			return err
+		}
+		for _, t := range l {
+			if t.ID == args[1] {
+				if err := a.RevokeToken(t.ID); err != nil {
+					return NewCommandError(StatusFail, "token: %s", err)
+				}
+				return nil
+			}
+		}
+		return NewCommandError(StatusNotFound, "token: %s: not found", args[1])
+	default:
+		return usage
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/utils/cmdline"
+)
+
+// Command exit status codes.
+const (
+	StatusOK       int = 0
+	StatusFail     int = 1
+	StatusUsage    int = 2
+	StatusDenied   int = 126
+	StatusNotFound int = 127
+)
+
+var errLogout error = errors.New("logout")
+
+// CommandFunc runs a console command writing its output to out.
+type CommandFunc func(ctx context.Context, out io.Writer, args []string) error
+
+// Command defines a console command. Role is the minimum access level
+// required to run it.
+type Command struct {
+	Name  string
+	Usage string
+	Help  string
+	Role  auth.Role
+	Run   CommandFunc
+}
+
+// CommandError is returned by commands that failed to run.
+type CommandError struct {
+	Status int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+// NewCommandError creates a new command error with the given exit status.
+func NewCommandError(status int, format string, args ...interface{}) error {
+	return &CommandError{Status: status, Err: fmt.Errorf(format, args...)}
+}
+
+// CommandStatus returns the exit status for the given command run error.
+func CommandStatus(err error) int {
+	if err == nil || err == errLogout {
+		return StatusOK
+	}
+	var cerr *CommandError
+	if errors.As(err, &cerr) {
+		return cerr.Status
+	}
+	return StatusFail
+}
+
+type registry struct {
+	rw *sync.RWMutex
+	db map[string]*Command
+}
+
+func newRegistry() *registry {
+	return &registry{rw: new(sync.RWMutex), db: make(map[string]*Command)}
+}
+
+func (r *registry) add(c *Command) error {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	if c.Name == "" || c.Run == nil {
+		return fmt.Errorf("console: invalid command %q", c.Name)
+	}
+	if _, found := r.db[c.Name]; found {
+		return fmt.Errorf("console: command %q already exists", c.Name)
+	}
+	r.db[c.Name] = c
+	return nil
+}
+
+func (r *registry) get(name string) (*Command, bool) {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	c, found := r.db[name]
+	return c, found
+}
+
+func (r *registry) list() []*Command {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	l := make([]*Command, 0, len(r.db))
+	for _, c := range r.db {
+		l = append(l, c)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Name < l[j].Name })
+	return l
+}
+
+// AddCommand registers a new console command.
+func (s *Console) AddCommand(c *Command) error {
+	return s.cmds.add(c)
+}
+
+// Eval parses and runs the command line, writing its output to out.
+func (s *Console) Eval(ctx context.Context, out io.Writer, line string) error {
+	args, err := cmdline.Split(line)
+	if err != nil {
+		return NewCommandError(StatusUsage, "%s", err)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	c, found := s.cmds.get(args[0])
+	if !found {
+		return NewCommandError(StatusNotFound, "%s: command not found", args[0])
+	}
+	if !s.ctxRole(ctx).Allows(c.Role) {
+		return NewCommandError(StatusDenied, "%s: permission denied", args[0])
+	}
+	return c.Run(ctx, out, args[1:])
+}
+
+func writeError(out io.Writer, err error) error {
+	_, err = fmt.Fprintf(out, "[ERROR] %s\n", err)
+	return err
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", blob)
+	return err
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package console
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/testing/assert"
+)
+
+func TestEval(t *testing.T) {
+	check := assert.New(t)
+	s := New()
+	ctx := s.ctxWithRole(context.Background(), auth.Admin)
+	buf := new(bytes.Buffer)
+
+	check.NoError(s.Eval(ctx, buf, ""))
+	check.Equal(errLogout, s.Eval(ctx, buf, "exit"))
+	check.Equal(StatusOK, CommandStatus(s.Eval(ctx, buf, "logout")))
+
+	err := s.Eval(ctx, buf, "nocmd")
+	check.EqualError(err, "nocmd: command not found")
+	check.Equal(StatusNotFound, CommandStatus(err))
+
+	err = s.Eval(ctx, buf, "status")
+	check.EqualError(err, "master robot not available")
+	check.Equal(StatusFail, CommandStatus(err))
+
+	err = s.Eval(ctx, buf, "devices")
+	check.Equal(StatusUsage, CommandStatus(err))
+
+	buf.Reset()
+	check.NoError(s.Eval(ctx, buf, "help help"))
+	check.Equal("help [command]\n  show commands help\n", buf.String())
+}
+
+func TestAddCommand(t *testing.T) {
+	check := assert.New(t)
+	s := New()
+	c := &Command{
+		Name: "testing",
+		Run: func(ctx context.Context, out io.Writer, args []string) error {
+			_, err := io.WriteString(out, "testing")
+			return err
+		},
+	}
+	check.NoError(s.AddCommand(c))
+	check.Error(s.AddCommand(c), "command exists")
+	check.Error(s.AddCommand(&Command{Name: "norun"}), "command without run")
+	buf := new(bytes.Buffer)
+	ctx := s.ctxWithRole(context.Background(), auth.Viewer)
+	check.NoError(s.Eval(ctx, buf, "testing"))
+	check.Equal("testing", buf.String())
+}
+
+func TestEvalRole(t *testing.T) {
+	check := assert.New(t)
+	s := New()
+	buf := new(bytes.Buffer)
+
+	err := s.Eval(context.Background(), buf, "help")
+	check.EqualError(err, "help: permission denied", "no role")
+	check.Equal(StatusDenied, CommandStatus(err))
+
+	ctx := s.ctxWithRole(context.Background(), auth.Viewer)
+	check.NoError(s.Eval(ctx, buf, "help"))
+	check.Equal(StatusDenied, CommandStatus(s.Eval(ctx, buf, "master exit")))
+	check.Equal(StatusDenied, CommandStatus(s.Eval(ctx, buf, "reload")))
+	check.Equal(StatusDenied, CommandStatus(s.Eval(ctx, buf, "cmd r d c")))
+
+	ctx = s.ctxWithRole(context.Background(), auth.Operator)
+	check.Equal(StatusDenied, CommandStatus(s.Eval(ctx, buf, "master exit")))
+	check.Equal(StatusDenied, CommandStatus(s.Eval(ctx, buf, "reload")))
+	check.Equal(StatusFail, CommandStatus(s.Eval(ctx, buf, "cmd r d c")))
+}
+
+func TestMasterRole(t *testing.T) {
+	check := assert.New(t)
+	check.Equal(auth.Viewer, MasterRole("status"))
+	check.Equal(auth.Admin, MasterRole("reload"))
+	check.Equal(auth.Admin, MasterRole("exit"))
+	check.Equal(auth.Admin, MasterRole("other"))
+}
+
+func TestWho(t *testing.T) {
+	check := assert.New(t)
+	s := New()
+	a := auth.New()
+	s.auth = a
+	for _, sid := range []string{"s0", "s1"} {
+		sess := auth.NewSession(sid, "127.0.0.1:"+sid, time.Now())
+		sess.Fingerprint = "key-" + sid
+		check.NoError(a.Login(sess))
+	}
+	ctx, sid := s.ctxNewSession(context.Background())
+	check.NoError(a.Login(auth.NewSession(sid, "127.0.0.1:me", time.Now())))
+	buf := new(bytes.Buffer)
+
+	check.NoError(s.Eval(s.ctxWithRole(ctx, auth.Viewer), buf, "who"))
+	check.Contains(buf.String(), "*"+sid+" ")
+	check.NotContains(buf.String(), "key-s0")
+	check.NotContains(buf.String(), "key-s1")
+
+	buf.Reset()
+	check.NoError(s.Eval(s.ctxWithRole(ctx, auth.Operator), buf, "who"))
+	check.Contains(buf.String(), "*"+sid+" ")
+	check.Contains(buf.String(), "key-s0")
+	check.Contains(buf.String(), "key-s1")
+}
+
+func TestWatch(t *testing.T) {
+	check := assert.New(t)
+	s := New()
+	ctx := s.ctxWithRole(context.Background(), auth.Viewer)
+	buf := new(bytes.Buffer)
+	check.Equal(StatusUsage, CommandStatus(s.Eval(ctx, buf, "watch -n")))
+	check.Equal(StatusUsage, CommandStatus(s.Eval(ctx, buf, "watch -n x")))
+	check.Equal(StatusUsage, CommandStatus(s.Eval(ctx, buf, "watch r d e x")))
+	check.Equal(StatusFail, CommandStatus(s.Eval(ctx, buf, "watch")))
+
+	m := master.NewRobot()
+	s.master = m
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Eval(ctx, buf, "watch -n 1 * d0")
+	}()
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+LOOP:
+	for {
+		select {
+		case err := <-done:
+			check.NoError(err)
+			break LOOP
+		case <-tick.C:
+			m.Events().Publish("r0", "d1", "e0", nil)
+			m.Events().Publish("r0", "d0", "e0", nil)
+		}
+	}
+	check.Regexp(`^\{"id":\d+,"time":"[^"]+","robot":"r0","device":"d0","event":"e0"\}\n$`, buf.String())
+
+	// session closed
+	cctx, cancel := context.WithCancel(ctx)
+	go cancel()
+	check.NoError(s.Eval(cctx, buf, "watch"))
+
+	// user input
+	for _, key := range []string{"q", "\x03"} {
+		r, w := io.Pipe()
+		cctx, cancel := context.WithCancel(ctx)
+		cctx = s.ctxWithInput(cctx, s.newChanInput(cctx, r))
+		go w.Write([]byte(key))
+		check.NoError(s.Eval(cctx, buf, "watch"), key)
+		cancel()
+	}
+	r, w := io.Pipe()
+	cctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	cctx = s.ctxWithInput(cctx, s.newChanInput(cctx, r))
+	w.Close()
+	check.NoError(s.Eval(cctx, buf, "watch"), "input EOF")
+}
@@ -9,27 +9,41 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 
 	"github.com/munbot/master/config/profile"
 	"github.com/munbot/master/internal/auth"
 	"github.com/munbot/master/log"
+	"github.com/munbot/master/robot/master"
 )
 
+var stopTimeout time.Duration = 30 * time.Second
+
 // Config is the server config.
 type Config struct {
 	Enable bool
 	Addr   string
 	Port   uint
 	Auth   auth.Manager
+	Master master.Munbot
+	// ListenFile is a socket passed by the service manager. If not nil, it's
+	// used instead of listening on Addr.
+	ListenFile *os.File
 }
 
 type Server interface {
 	Configure(*Config) error
 	Start() error
 	Stop() error
+	AddCommand(*Command) error
+	Ready() bool
 }
 
 type Addr struct {
@@ -65,6 +79,8 @@ var _ Server = &Console{}
 type Console struct {
 	enable bool
 	auth   auth.Manager
+	master master.Munbot
+	cmds   *registry
 	cfg    *ssh.ServerConfig
 	done   chan bool
 	addr   string
@@ -74,16 +90,21 @@ type Console struct {
 	q      map[string]net.Conn
 	closed bool
 	wgc    map[string]int
+	ready  int32
+	lnfile *os.File
 }
 
 func New() *Console {
-	return &Console{
+	s := &Console{
+		cmds: newRegistry(),
 		done: make(chan bool, 1),
 		wg:   &sync.WaitGroup{},
 		lock: new(sync.Mutex),
 		q:    make(map[string]net.Conn),
 		wgc:  make(map[string]int),
 	}
+	s.addBuiltins()
+	return s
 }
 
 func (s *Console) wgadd(n string) {
@@ -100,11 +121,32 @@ func (s *Console) wgdone(n string) {
 	s.wgc[n] -= 1
 }
 
-func (s *Console) wgwait() {
+// wgwait waits for the running goroutines to finish, up to stopTimeout.
+func (s *Console) wgwait() error {
 	s.lock.Lock()
 	log.Debugf("wgwait %v", s.wgc)
 	s.lock.Unlock()
-	s.wg.Wait()
+	done := make(chan bool)
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(stopTimeout):
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pending := make([]string, 0)
+	for n, c := range s.wgc {
+		if c > 0 {
+			pending = append(pending, fmt.Sprintf("%s:%d", n, c))
+		}
+	}
+	sort.Strings(pending)
+	return fmt.Errorf("console stop timeout after %s, pending %s", stopTimeout,
+		strings.Join(pending, " "))
 }
 
 func (s *Console) Addr() *Addr {
@@ -116,8 +158,9 @@ func (s *Console) Addr() *Addr {
 }
 
 func (s *Console) Configure(cfg *Config) error {
+	s.enable = cfg.Enable
 	if cfg.Enable {
-		s.enable = true
+		s.master = cfg.Master
 		s.auth = cfg.Auth
 		if s.auth == nil {
 			p := profile.New()
@@ -129,13 +172,21 @@ func (s *Console) Configure(cfg *Config) error {
 		}
 		s.cfg = s.auth.ServerConfig()
 		s.addr = fmt.Sprintf("%s:%d", cfg.Addr, cfg.Port)
+		s.lnfile = cfg.ListenFile
 	}
 	return nil
 }
 
+// Ready returns true if the server is listening for connections or if it's
+// disabled.
+func (s *Console) Ready() bool {
+	return !s.enable || atomic.LoadInt32(&s.ready) == 1
+}
+
 func (s *Console) Stop() error {
 	log.Debug("stop")
 	if s.enable {
+		atomic.StoreInt32(&s.ready, 0)
 		defer close(s.done)
 		s.closed = true
 		s.done <- true
@@ -144,7 +195,9 @@ func (s *Console) Stop() error {
 			err = s.ln.Close()
 		}
 		log.Debug("wait for them to finish...")
-		s.wgwait()
+		if werr := s.wgwait(); werr != nil {
+			return werr
+		}
 		return err
 	}
 	log.Debug("api server is disabled")
@@ -155,13 +208,25 @@ func (s *Console) Start() error {
 	log.Debug("start")
 	if s.enable {
 		var err error
+		// a stopped server closes its done channel
+		s.done = make(chan bool, 1)
+		s.closed = false
 		// listen
-		s.ln, err = net.Listen("tcp", s.addr)
+		if s.lnfile != nil {
+			s.ln, err = net.FileListener(s.lnfile)
+			if err == nil {
+				s.addr = s.ln.Addr().String()
+			}
+		} else {
+			s.ln, err = net.Listen("tcp", s.addr)
+		}
 		if err != nil {
 			log.Debugf("listen error: %v", err)
 			return err
 		}
 		log.Printf("Console server ssh://%s", s.addr)
+		atomic.StoreInt32(&s.ready, 1)
+		defer atomic.StoreInt32(&s.ready, 0)
 		// accept connections
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -196,9 +261,13 @@ LOOP:
 			}
 			continue
 		}
+		if err := s.auth.Allow(nc.RemoteAddr()); err != nil {
+			log.Debugf("%s: %v", nc.RemoteAddr(), err)
+			nc.Close()
+			continue
+		}
 		// ctx session
-		var sid string
-		ctx, sid = s.ctxNewSession(ctx)
+		sctx, sid := s.ctxNewSession(ctx)
 		log.Debugf("new session %s", sid)
 		// dispatch
 		s.wgadd("dispatch")
@@ -214,7 +283,7 @@ LOOP:
 			log.Debugf("%s dequeue", sid)
 			delete(s.q, sid)
 			s.lock.Unlock()
-		}(ctx, nc, sid)
+		}(sctx, nc, sid)
 	}
 	log.Debug("check active connections...")
 	s.lock.Lock()
@@ -239,8 +308,12 @@ func (s *Console) dispatch(ctx context.Context, nc net.Conn, sid string) {
 		return
 	default:
 	}
+	// cancelled when the connection is done or the session is kicked
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sess := auth.NewSession(sid, nc.RemoteAddr().String(), s.ctxSessionStart(ctx))
 	// ssh handshake
-	conn, chans, reqs, err := ssh.NewServerConn(nc, s.cfg)
+	conn, chans, reqs, err := ssh.NewServerConn(&sessConn{nc, sess}, s.cfg)
 	if err != nil {
 		log.Debugf("%s handshake error: %v", sid, err)
 		return
@@ -252,8 +325,15 @@ func (s *Console) dispatch(ctx context.Context, nc net.Conn, sid string) {
 		ssh.DiscardRequests(reqs)
 	}(reqs)
 	// serve
-	fp := conn.Permissions.Extensions["pubkey-fp"]
-	if err := s.auth.Login(fp, sid); err != nil {
+	ctx = s.ctxWithPerms(ctx, conn.Permissions)
+	sess.Fingerprint = conn.Permissions.Extensions[auth.ExtFingerprint]
+	sess.Role = s.ctxRole(ctx)
+	sess.Close = func() error {
+		cancel()
+		return conn.Close()
+	}
+	log.Debugf("%s role %s", sid, sess.Role)
+	if err := s.auth.Login(sess); err != nil {
 		log.Debugf("%s auth login error: %v", sid, err)
 		return
 	}
@@ -0,0 +1,27 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestWGWait(t *testing.T) {
+	check := require.New(t)
+	defer func(d time.Duration) { stopTimeout = d }(stopTimeout)
+	stopTimeout = 10 * time.Millisecond
+	s := New()
+	check.NoError(s.wgwait())
+	s.wgadd("dispatch")
+	s.wgadd("dispatch")
+	s.wgadd("testing")
+	s.wgdone("testing")
+	check.EqualError(s.wgwait(), "console stop timeout after 10ms, pending dispatch:2")
+	s.wgdone("dispatch")
+	s.wgdone("dispatch")
+	check.NoError(s.wgwait())
+}
@@ -7,6 +7,9 @@ import (
 	"context"
 	"time"
 
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/internal/auth"
 	"github.com/munbot/master/utils/uuid"
 )
 
@@ -15,6 +18,9 @@ type ctxKey int
 const (
 	ctxSession ctxKey = iota
 	ctxBorn
+	ctxRole
+	ctxPerms
+	ctxInput
 )
 
 func (s *Console) ctxNewSession(ctx context.Context) (context.Context, string) {
@@ -23,11 +29,54 @@ func (s *Console) ctxNewSession(ctx context.Context) (context.Context, string) {
 	return context.WithValue(ctx, ctxSession, sid), sid
 }
 
+func (s *Console) ctxWithInput(ctx context.Context, in *chanInput) context.Context {
+	return context.WithValue(ctx, ctxInput, in)
+}
+
+// ctxInput returns the channel input, or nil if there is none.
+func (s *Console) ctxInput(ctx context.Context) *chanInput {
+	in, _ := ctx.Value(ctxInput).(*chanInput)
+	return in
+}
+
 func (s *Console) ctxSession(ctx context.Context) string {
 	return ctx.Value(ctxSession).(string)
 }
 
+func (s *Console) ctxSessionStart(ctx context.Context) time.Time {
+	return ctx.Value(ctxBorn).(time.Time)
+}
+
 func (s *Console) ctxSessionElapsed(ctx context.Context) time.Duration {
-	born := ctx.Value(ctxBorn).(time.Time)
-	return time.Since(born)
+	return time.Since(s.ctxSessionStart(ctx))
+}
+
+func (s *Console) ctxWithRole(ctx context.Context, r auth.Role) context.Context {
+	return context.WithValue(ctx, ctxRole, r)
+}
+
+func (s *Console) ctxRole(ctx context.Context) auth.Role {
+	r, ok := ctx.Value(ctxRole).(auth.Role)
+	if !ok {
+		return auth.RoleNone
+	}
+	return r
+}
+
+func (s *Console) ctxWithPerms(ctx context.Context, p *ssh.Permissions) context.Context {
+	r, err := auth.ParseRole(p.Extensions[auth.ExtRole])
+	if err != nil {
+		r = auth.RoleNone
+	}
+	ctx = s.ctxWithRole(ctx, r)
+	return context.WithValue(ctx, ctxPerms, p)
+}
+
+func (s *Console) ctxExtension(ctx context.Context, name string) (string, bool) {
+	p, ok := ctx.Value(ctxPerms).(*ssh.Permissions)
+	if !ok || p == nil {
+		return "", false
+	}
+	v, found := p.Extensions[name]
+	return v, found
 }
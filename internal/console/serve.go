@@ -4,22 +4,30 @@
 package console
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
-	"net/textproto"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/auth"
 	"github.com/munbot/master/log"
 )
 
 type request struct {
-	Type  string
-	Serve bool
+	Type    string
+	Serve   bool
+	Command string
+}
+
+type execPayload struct {
+	Command string
+}
+
+type exitStatus struct {
+	Status uint32
 }
 
 func (s *Console) serve(ctx context.Context, nc ssh.NewChannel, sid string) {
@@ -36,11 +44,15 @@ func (s *Console) serve(ctx context.Context, nc ssh.NewChannel, sid string) {
 		log.Errorf("Console %s could not accept channel: %v", sid, err)
 		return
 	}
+	// cancelled when the channel is closed or its command is done
+	ctx, cancel := context.WithCancel(ctx)
+	ctx = s.ctxWithInput(ctx, s.newChanInput(ctx, ch))
 	reqs := make(chan request, 1)
 	s.wgadd("serve-request")
 	go func(ctx context.Context, in <-chan *ssh.Request, out chan<- request) {
 		log.Debugf("%s serve request", sid)
 		defer s.wgdone("serve-request")
+		defer cancel()
 		for req := range in {
 			select {
 			case <-ctx.Done():
@@ -51,24 +63,54 @@ func (s *Console) serve(ctx context.Context, nc ssh.NewChannel, sid string) {
 			}
 			log.Debugf("%s request type %s", sid, req.Type)
 			serve := false
+			command := ""
 			switch req.Type {
-			case "pty-req", "env", "shell":
+			case "pty-req":
+				_, noPty := s.ctxExtension(ctx, auth.ExtNoPty)
+				serve = !noPty
+			case "env", "shell":
 				serve = true
+			case "exec":
+				var p execPayload
+				if err := ssh.Unmarshal(req.Payload, &p); err != nil {
+					log.Errorf("%s exec request: %v", sid, err)
+				} else {
+					serve = true
+					command = p.Command
+				}
 			}
 			req.Reply(serve, nil)
-			out <- request{Type: req.Type, Serve: serve}
+			out <- request{Type: req.Type, Serve: serve, Command: command}
 		}
 	}(ctx, chr, reqs)
 	s.wgadd("serve")
 	go func(ctx context.Context, ch ssh.Channel, in <-chan request) {
 		defer s.wgdone("serve")
+		defer cancel()
 		wait := true
 		for wait {
 			req := <-in
+			if forced, ok := s.ctxExtension(ctx, auth.ExtForceCommand); ok && req.Serve {
+				if req.Type == "shell" || req.Type == "exec" {
+					log.Debugf("%s force command: %s", sid, forced)
+					wait = false
+					s.serveExec(ctx, ch, sid, forced)
+					continue
+				}
+			}
 			switch req.Type {
 			case "shell":
 				wait = false
 				s.serveShell(ctx, ch, sid)
+			case "exec":
+				if req.Serve {
+					wait = false
+					s.serveExec(ctx, ch, sid, req.Command)
+				} else {
+					log.Errorf("%s ssh invalid request: %s", sid, req.Type)
+					wait = false
+					ch.Close()
+				}
 			default:
 				if !req.Serve {
 					log.Errorf("%s ssh invalid request: %s", sid, req.Type)
@@ -84,8 +126,10 @@ func (s *Console) serveShell(ctx context.Context, ch ssh.Channel, sid string) {
 	log.Debugf("%s serve shell", sid)
 	defer ch.Close()
 	ps1 := fmt.Sprintf("%s> ", env.Get("MUNBOT"))
-	term := terminal.NewTerminal(ch, ps1)
-	resp := textproto.NewWriter(bufio.NewWriter(term))
+	term := terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{s.ctxInput(ctx), ch}, ps1)
 LOOP:
 	for {
 		select {
@@ -104,13 +148,39 @@ LOOP:
 			break LOOP
 		}
 		log.Printf("%s SHELL: %s", sid, line)
-		if err := resp.PrintfLine("%q", line); err != nil {
-			log.Errorf("Console %s: %v", sid, err)
-			return
+		if err := s.Eval(ctx, term, line); err != nil {
+			if err == errLogout {
+				return
+			}
+			log.Debugf("%s command error: %v", sid, err)
+			if err := writeError(term, err); err != nil {
+				log.Errorf("Console %s: %v", sid, err)
+				return
+			}
 		}
 	}
 	term.SetPrompt("")
-	if err := resp.PrintfLine("%s%s", ps1, "logout"); err != nil {
+	if _, err := fmt.Fprintf(term, "%s%s\n", ps1, "logout"); err != nil {
+		if err != io.EOF {
+			log.Errorf("Console %s: %v", sid, err)
+		}
+	}
+}
+
+func (s *Console) serveExec(ctx context.Context, ch ssh.Channel, sid, line string) {
+	log.Debugf("%s serve exec", sid)
+	defer ch.Close()
+	log.Printf("%s EXEC: %s", sid, line)
+	err := s.Eval(ctx, ch, line)
+	st := CommandStatus(err)
+	if err != nil && err != errLogout {
+		log.Debugf("%s command error: %v", sid, err)
+		if err := writeError(ch.Stderr(), err); err != nil {
+			log.Errorf("Console %s: %v", sid, err)
+		}
+	}
+	log.Debugf("%s exit status %d", sid, st)
+	if _, err := ch.SendRequest("exit-status", false, ssh.Marshal(&exitStatus{uint32(st)})); err != nil {
 		if err != io.EOF {
 			log.Errorf("Console %s: %v", sid, err)
 		}
@@ -0,0 +1,79 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package console
+
+import (
+	"context"
+	"io"
+	"net"
+
+	"github.com/munbot/master/internal/auth"
+)
+
+// sessConn updates the session activity and bytes counters.
+type sessConn struct {
+	net.Conn
+	sess *auth.Session
+}
+
+func (c *sessConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.sess.Received(n)
+	}
+	return n, err
+}
+
+func (c *sessConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.sess.Sent(n)
+	}
+	return n, err
+}
+
+// chanInput reads the channel input from its own goroutine, so a running
+// command can check it (for Ctrl-C in example) and the shell still gets what
+// is typed after the command is done. The chunks channel is closed on EOF.
+type chanInput struct {
+	c   chan []byte
+	buf []byte
+}
+
+func (s *Console) newChanInput(ctx context.Context, r io.Reader) *chanInput {
+	in := &chanInput{c: make(chan []byte)}
+	s.wgadd("chan-input")
+	go func() {
+		defer s.wgdone("chan-input")
+		defer close(in.c)
+		for {
+			b := make([]byte, 256)
+			n, err := r.Read(b)
+			if n > 0 {
+				select {
+				case in.c <- b[:n]:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return in
+}
+
+func (in *chanInput) Read(b []byte) (int, error) {
+	if len(in.buf) == 0 {
+		blob, ok := <-in.c
+		if !ok {
+			return 0, io.EOF
+		}
+		in.buf = blob
+	}
+	n := copy(b, in.buf)
+	in.buf = in.buf[n:]
+	return n, nil
+}
@@ -71,12 +71,18 @@ func (s *sshCmdSuite) SetupSuite() {
 	if err := s.cons.Configure(s.cfg); err != nil {
 		s.T().Fatal(err)
 	}
+	if s.cons.Ready() {
+		s.T().Fatal("console ready before start")
+	}
 	go func(t *testing.T, c console.Server) {
 		if err := c.Start(); err != nil {
 			t.Fatal(err)
 		}
 	}(s.T(), s.cons)
 	time.Sleep(50 * time.Millisecond)
+	if !s.cons.Ready() {
+		s.T().Fatal("console not ready after start")
+	}
 	s.addr = s.cons.Addr().String()
 	s.T().Logf("console addr %q", s.addr)
 	if s.addr == "ssh:" {
@@ -127,7 +133,17 @@ var allTests map[string]*sshcmdTest = map[string]*sshcmdTest{
 	"Connect":   {[]string{}, 255, "master> logout"},
 	"PtyReq":    {[]string{"-tt"}, 255, "master> logout"},
 	"Tunnel":    {[]string{"-tt", "-L", "9999:localhost:6492"}, 255, "master> logout"},
-	"ExecError": {[]string{"testing"}, 255, ""},
+	"Exec":      {[]string{"help"}, 0, "show commands help"},
+	"ExecQuote": {[]string{"help", "'exit'"}, 0, "close the session"},
+	"ExecFail":  {[]string{"uptime"}, 1, "[ERROR] master robot not available"},
+	"ExecUsage": {[]string{"devices"}, 2, "[ERROR] usage: devices robot"},
+	"ExecError": {[]string{"testing"}, 127, "[ERROR] testing: command not found"},
+	"ExecExit":  {[]string{"exit"}, 0, ""},
+	"ExecWho":   {[]string{"who"}, 0, " role=admin remote=127.0.0.1:"},
+	"ExecKick":  {[]string{"kick", "testing"}, 127, "[ERROR] kick: auth: session testing not found"},
+	"ExecAudit": {[]string{"audit", "1"}, 0, `"event":"login"`},
+	"ExecToken": {[]string{"token", "new", "testing", "viewer"}, 0, " role=viewer"},
+	"ExecWatch": {[]string{"watch", "-n", "0"}, 2, `[ERROR] watch: invalid count "0"`},
 }
 
 func (s *sshCmdSuite) TestAll() {
@@ -193,7 +209,7 @@ func (s *sshCmdSuite) TestSSHIdentError() {
 	s.Equal("", buf.String())
 }
 
-func (s *sshCmdSuite) TestSSHCopyIDError() {
+func (s *sshCmdSuite) TestSSHCopyID() {
 	command, err := exec.LookPath("ssh-copy-id")
 	if err != nil {
 		s.T().Skip(err)
@@ -207,10 +223,10 @@ func (s *sshCmdSuite) TestSSHCopyIDError() {
 	cmd.Stdout = buf
 	cmd.Stderr = buf
 	err = cmd.Run()
-	s.Error(err)
+	s.NoError(err)
 	st := cmd.ProcessState
-	s.Equal(1, st.ExitCode())
-	s.Contains(buf.String(), "ERROR: exec request failed on channel 0")
+	s.Equal(0, st.ExitCode())
+	s.Contains(buf.String(), "All keys were skipped because they already exist")
 }
 
 func (s *sshCmdSuite) TestSCPError() {
@@ -0,0 +1,118 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"time"
+
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/api/wapp"
+	"github.com/munbot/master/internal/auth"
+)
+
+var _ wapp.Runtime = &apiRuntime{}
+
+// apiRuntime provides the core runtime to the munbot api.
+type apiRuntime struct {
+	m  Machine
+	rt *Mem
+}
+
+func newApiRuntime(m Machine, rt *Mem) *apiRuntime {
+	return &apiRuntime{m: m, rt: rt}
+}
+
+func (a *apiRuntime) Name() string {
+	return env.Get("MUNBOT")
+}
+
+func (a *apiRuntime) State() string {
+	return a.m.State()
+}
+
+func (a *apiRuntime) Uptime() time.Duration {
+	return a.rt.Master.Uptime()
+}
+
+func (a *apiRuntime) Profile() string {
+	return a.m.ConfigFlags().Profile.Name
+}
+
+func (a *apiRuntime) Env() map[string]string {
+	m := make(map[string]string, len(env.Init))
+	for k := range env.Init {
+		m[k] = env.Get(k)
+	}
+	return m
+}
+
+func (a *apiRuntime) Config() map[string]string {
+	return config.NewParser(a.m.Config()).Map("")
+}
+
+func (a *apiRuntime) Explain(filter string) []*wapp.ExplainInfo {
+	l := a.m.Config().Explain(filter)
+	r := make([]*wapp.ExplainInfo, 0, len(l))
+	for _, x := range l {
+		i := &wapp.ExplainInfo{Name: x.Name, Value: x.Value, Source: x.Source}
+		for _, o := range x.Overrides {
+			i.Overrides = append(i.Overrides, &wapp.LayerInfo{Source: o.Source, Value: o.Value})
+		}
+		r = append(r, i)
+	}
+	return r
+}
+
+func (a *apiRuntime) Sessions() []*auth.SessionInfo {
+	return a.rt.Auth.Sessions()
+}
+
+func (a *apiRuntime) Services() []*wapp.ServiceInfo {
+	l := a.rt.Services.Status()
+	r := make([]*wapp.ServiceInfo, 0, len(l))
+	for _, st := range l {
+		i := &wapp.ServiceInfo{
+			Name:     st.Name,
+			Status:   st.Status,
+			Restarts: st.Restarts,
+			Since:    st.Since,
+		}
+		if st.Err != nil {
+			i.Error = st.Err.Error()
+		}
+		r = append(r, i)
+	}
+	return r
+}
+
+func (a *apiRuntime) Transitions() []*wapp.TransitionInfo {
+	l := a.m.Transitions()
+	r := make([]*wapp.TransitionInfo, 0, len(l))
+	for _, t := range l {
+		r = append(r, &wapp.TransitionInfo{
+			From:  StateName(t.From),
+			To:    StateName(t.To),
+			Time:  t.Time,
+			Cause: t.Cause,
+		})
+	}
+	return r
+}
+
+// Reload requests a config reload, which is done in background.
+func (a *apiRuntime) Reload() error {
+	if a.m.StateID() != Run {
+		return ErrReload
+	}
+	return a.rt.Master.Reload()
+}
+
+// Stop requests the master robot to exit, which makes the core stop running.
+func (a *apiRuntime) Stop() error {
+	if a.m.StateID() != Run {
+		return ErrStop
+	}
+	return a.rt.Master.Exit()
+}
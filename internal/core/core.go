@@ -8,11 +8,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/munbot/master/config"
 	"github.com/munbot/master/log"
 	"github.com/munbot/master/utils/uuid"
 	"github.com/munbot/master/version"
+
+	// runtime config options
+	_ "github.com/munbot/master/config/options"
 )
 
 var _ Runtime = &Core{}
@@ -26,9 +30,13 @@ type Core struct {
 	cfl   *config.Flags
 	state State
 	stid  StateID
+	mu    *sync.Mutex
 	sInit State
 	sRun  State
 	sHalt State
+
+	onTrans []TransitionFunc
+	history []Transition
 }
 
 var mem *Mem
@@ -39,13 +47,15 @@ func init() {
 
 func New(m *Mem) *Core {
 	log.Infof("Munbot version %s", version.String())
-	k := &Core{rt: m, uuid: uuid.Rand()}
+	k := &Core{rt: m, uuid: uuid.Rand(), mu: new(sync.Mutex)}
 	k.sInit = newInit(k, k.rt)
 	k.sRun = newRun(k, k.rt)
 	k.sHalt = newHalt(k, k.rt)
+	k.OnTransition(k.sdStatus)
 	// init state
-	k.state = k.sInit
-	k.stid = Init
+	if err := k.transition(Init, "new"); err != nil {
+		panic(err)
+	}
 	return k
 }
 
@@ -62,10 +72,12 @@ func (k *Core) UUID() string {
 }
 
 func (k *Core) State() string {
-	return StateName(k.stid)
+	return StateName(k.StateID())
 }
 
 func (k *Core) StateID() StateID {
+	k.mu.Lock()
+	defer k.mu.Unlock()
 	return k.stid
 }
 
@@ -76,6 +88,6 @@ func (k *Core) error(err error) error {
 
 func (k *Core) errorf(f string, args ...interface{}) error {
 	err := errors.New(fmt.Sprintf(f, args...))
-	log.Output(2, fmt.Sprintf("[ERROR] core %s: %s", StateName(k.stid), err))
+	log.Output(2, fmt.Sprintf("[ERROR] core %s: %s", k.State(), err))
 	return err
 }
@@ -13,9 +13,18 @@ import (
 type Flags struct {
 	apiDisable     bool
 	apiDebug       bool
+	apiNet         string
 	apiAddr        string
 	apiPort        uint
+	apiTLS         bool
+	apiTLSCert     string
+	apiTLSKey      string
+	apiTLSClients  bool
+	apiTLSClientCA string
+	apiTLSRoles    string
 	authDisable    bool
+	authSessions   uint
+	authKeySess    uint
 	consoleDisable bool
 	consoleAddr    string
 	consolePort    uint
@@ -29,16 +38,25 @@ func NewFlags() *Flags {
 func (f *Flags) Set(fs *flag.FlagSet) {
 	fs.BoolVar(&f.apiDisable, "api.disable", false, "disable api server")
 	fs.BoolVar(&f.apiDebug, "api.debug", false, "debug api")
+	fs.StringVar(&f.apiNet, "api.net", "", "api `network`: tcp, tcp4, tcp6 or unix")
 	fs.StringVar(&f.apiAddr, "api.addr", "", "api tcp network `address`")
 	fs.UintVar(&f.apiPort, "api.port", 0, "api tcp port `number`")
+	fs.BoolVar(&f.apiTLS, "api.tls", false, "enable api https")
+	fs.StringVar(&f.apiTLSCert, "api.tls.cert", "", "api tls certificate `filename`")
+	fs.StringVar(&f.apiTLSKey, "api.tls.key", "", "api tls key `filename`")
+	fs.BoolVar(&f.apiTLSClients, "api.tls.clients", false, "verify api tls client certificates")
+	fs.StringVar(&f.apiTLSClientCA, "api.tls.clientca", "", "api tls client CA certificates `filename`")
+	fs.StringVar(&f.apiTLSRoles, "api.tls.roles", "", "api tls client roles `filename`")
 	fs.BoolVar(&f.authDisable, "auth.disable", false, "disable auth")
+	fs.UintVar(&f.authSessions, "auth.sessions", 0, "max `number` of concurrent sessions")
+	fs.UintVar(&f.authKeySess, "auth.key-sessions", 0, "max `number` of concurrent sessions per key")
 	fs.BoolVar(&f.consoleDisable, "console.disable", false, "disable console server")
 	fs.StringVar(&f.consoleAddr, "console.addr", "", "console tcp network `address`")
 	fs.UintVar(&f.consolePort, "console.port", 0, "console tcp port `number`")
 }
 
-// Parse parses the flags that were not set via the flags handler (cmd args
-// usually) and sets them with their respective values from the configuration.
+// Parse sets the env settings from the flags that were set via the flags handler
+// (cmd args usually). They take precedence over env files and config options.
 func (f *Flags) Parse() {
 	f.parseApi()
 	f.parseAuth()
@@ -52,18 +70,45 @@ func (f *Flags) parseApi() {
 	if f.apiDebug {
 		env.Set("MBAPI_DEBUG", "true")
 	}
+	if f.apiNet != "" {
+		env.Set("MBAPI_NET", f.apiNet)
+	}
 	if f.apiAddr != "" {
 		env.Set("MBAPI_ADDR", f.apiAddr)
 	}
 	if f.apiPort != 0 {
 		env.SetUint("MBAPI_PORT", f.apiPort)
 	}
+	if f.apiTLS {
+		env.Set("MBAPI_TLS", "true")
+	}
+	if f.apiTLSCert != "" {
+		env.Set("MBAPI_TLS_CERT", f.apiTLSCert)
+	}
+	if f.apiTLSKey != "" {
+		env.Set("MBAPI_TLS_KEY", f.apiTLSKey)
+	}
+	if f.apiTLSClients {
+		env.Set("MBAPI_TLS_CLIENTS", "true")
+	}
+	if f.apiTLSClientCA != "" {
+		env.Set("MBAPI_TLS_CLIENT_CA", f.apiTLSClientCA)
+	}
+	if f.apiTLSRoles != "" {
+		env.Set("MBAPI_TLS_CLIENT_ROLES", f.apiTLSRoles)
+	}
 }
 
 func (f *Flags) parseAuth() {
 	if f.authDisable {
 		env.Set("MBAUTH", "false")
 	}
+	if f.authSessions != 0 {
+		env.SetUint("MBAUTH_SESSIONS", f.authSessions)
+	}
+	if f.authKeySess != 0 {
+		env.SetUint("MBAUTH_KEY_SESSIONS", f.authKeySess)
+	}
 }
 
 func (f *Flags) parseConsole() {
@@ -18,6 +18,7 @@ func TestFlagsSet(t *testing.T) {
 	assert := assert.New(t)
 	f := NewFlags()
 	f.Set(newTestFS())
+	assert.Equal("", f.apiNet, "default api.net")
 	assert.Equal("", f.apiAddr, "default api.addr")
 	assert.Equal(uint(0), f.apiPort, "default api.port")
 }
@@ -27,6 +28,7 @@ func TestFlagsDefaults(t *testing.T) {
 	f := NewFlags()
 	f.Set(newTestFS())
 	f.Parse()
+	assert.Equal("", f.apiNet, "default api.net")
 	assert.Equal("", f.apiAddr, "default api.addr")
 	assert.Equal(uint(0), f.apiPort, "default api.port")
 	assert.Equal("", f.consoleAddr, "default console.addr")
@@ -10,9 +10,15 @@ import (
 
 type Machine interface {
 	Abort() error
-	SetState(StateID) error
+	SetState(StateID, string) error
 	Config() *config.Config
 	ConfigFlags() *config.Flags
+	State() string
+	StateID() StateID
+	Alive() bool
+	Ready() bool
+	Services() map[string]bool
+	Transitions() []Transition
 }
 
 func (k *Core) Abort() error {
@@ -28,23 +34,13 @@ func (k *Core) Abort() error {
 	return nil
 }
 
-func (k *Core) SetState(s StateID) error {
+// SetState changes the current state, if the transition is allowed. The cause
+// is kept in the transitions history.
+func (k *Core) SetState(s StateID, cause string) error {
 	log.Debugf("[%s] set state %s", k.State(), StateName(s))
-	if s == k.stid {
-		return k.errorf("core: state %s set twice", StateName(s))
+	if err := k.transition(s, cause); err != nil {
+		return k.error(err)
 	}
-	switch s {
-	case Init:
-		k.state = k.sInit
-	case Run:
-		k.state = k.sRun
-	case Halt:
-		k.state = k.sHalt
-	default:
-		k.errorf("core: set %s", StateName(s))
-	}
-	k.stid = s
-	k.rt.Master.CurrentState(k.State())
 	return nil
 }
 
@@ -55,3 +51,27 @@ func (k Core) Config() *config.Config {
 func (k Core) ConfigFlags() *config.Flags {
 	return k.cfl
 }
+
+// Alive returns true if the core is initializing or running.
+func (k *Core) Alive() bool {
+	s := k.StateID()
+	return s == Init || s == Run
+}
+
+// Ready returns true if the core is running and all its services are ready.
+func (k *Core) Ready() bool {
+	if k.StateID() != Run {
+		return false
+	}
+	for _, ok := range k.Services() {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Services returns the ready status of the runtime services.
+func (k *Core) Services() map[string]bool {
+	return k.rt.Services.Ready()
+}
@@ -5,26 +5,32 @@ package core
 
 import (
 	"errors"
+	"os"
 
 	"github.com/munbot/master/internal/api"
 	"github.com/munbot/master/internal/auth"
 	"github.com/munbot/master/internal/console"
 	"github.com/munbot/master/robot/master"
 	"github.com/munbot/master/utils/lock"
+	"github.com/munbot/master/utils/pidfile"
 )
 
 var ErrMemLock error = errors.New("mem lock failed")
 
 type Mem struct {
-	mu      *lock.Locker
-	Auth    auth.Manager
-	Api     api.Server
-	Console console.Server
-	Master  master.Munbot
+	mu       *lock.Locker
+	Auth     auth.Manager
+	Api      api.Server
+	Console  console.Server
+	Master   master.Munbot
+	Services *Registry
+	pid      *pidfile.File
+	// sockets passed by the service manager, by service name
+	sockets map[string]*os.File
 }
 
 func newMem() *Mem {
-	return &Mem{mu: lock.New()}
+	return &Mem{mu: lock.New(), Services: NewRegistry()}
 }
 
 func (m *Mem) Lock() error {
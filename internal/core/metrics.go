@@ -0,0 +1,44 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"github.com/munbot/master/internal/metrics"
+)
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// registerMetrics adds the runtime gauges to the default metrics registry.
+func registerMetrics(m Machine, rt *Mem) {
+	metrics.Register(metrics.NewGaugeValue("munbot_uptime_seconds",
+		"Master robot uptime.", func() float64 {
+			return rt.Master.Uptime().Seconds()
+		}))
+	metrics.Register(metrics.NewGaugeFunc("munbot_state",
+		"Core current state.", func() map[string]float64 {
+			cur := m.StateID()
+			v := make(map[string]float64)
+			for _, s := range []StateID{Init, Run, Halt} {
+				v[StateName(s)] = boolValue(s == cur)
+			}
+			return v
+		}, "state"))
+	metrics.Register(metrics.NewGaugeValue("munbot_console_sessions",
+		"Console live sessions.", func() float64 {
+			return float64(len(rt.Auth.Sessions()))
+		}))
+	metrics.Register(metrics.NewGaugeFunc("munbot_services_ready",
+		"Runtime services ready status.", func() map[string]float64 {
+			v := make(map[string]float64)
+			for n, ok := range m.Services() {
+				v[n] = boolValue(ok)
+			}
+			return v
+		}, "service"))
+}
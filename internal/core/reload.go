@@ -0,0 +1,162 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/systemd"
+	"github.com/munbot/master/log"
+)
+
+var ErrReload error = errors.New("can not Reload in this state")
+
+// reloadKeys are the env settings used by each reloadable service. If any of
+// them changes, the service is reconfigured.
+var reloadKeys map[string][]string = map[string][]string{
+	"log": {"MB_LOG", "MB_LOG_COLORS"},
+	"auth": {"MBAUTH", "MBAUTH_SESSIONS", "MBAUTH_KEY_SESSIONS", "MBAUTH_MAX_TRIES",
+		"MBAUTH_BAN_TRIES", "MBAUTH_BAN_TIME"},
+	"api": {"MBAPI", "MBAPI_NET", "MBAPI_ADDR", "MBAPI_PORT", "MBAPI_TLS",
+		"MBAPI_TLS_CERT", "MBAPI_TLS_KEY", "MBAPI_TLS_CLIENTS", "MBAPI_TLS_CLIENT_CA",
+		"MBAPI_TLS_CLIENT_ROLES"},
+	"console": {"MBCONSOLE", "MBCONSOLE_ADDR", "MBCONSOLE_PORT"},
+}
+
+// reloadIgnore are the env settings that do not affect the running services.
+var reloadIgnore map[string]bool = map[string]bool{
+	"MBAPI_TOKEN":     true,
+	"MB_STOP_TIMEOUT": true,
+}
+
+// reloadChanges returns the services affected by the changed env settings.
+// Changed settings that can not be applied without a restart are logged.
+func reloadChanges(prev, cur map[string]string) map[string]bool {
+	svc := make(map[string]bool)
+	keys := make([]string, 0)
+	for k, v := range cur {
+		if prev[k] != v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+KEYS:
+	for _, k := range keys {
+		log.Debugf("reload changed %s", k)
+		for name, l := range reloadKeys {
+			for _, x := range l {
+				if k == x {
+					svc[name] = true
+					continue KEYS
+				}
+			}
+		}
+		if !reloadIgnore[k] {
+			log.Warnf("Reload: %s changed, restart is needed to apply it", k)
+		}
+	}
+	return svc
+}
+
+// reload reads the env and config files again and reconfigures the services
+// which settings were changed. If that fails, previous settings are restored.
+// Master robots are not affected.
+func (s *SRun) reload() error {
+	log.Print("Reload...")
+	sdNotify(systemd.Reloading)
+	// the service manager waits for it, even if the reload failed
+	defer sdNotify(systemd.Ready)
+	prevEnv := env.Snapshot()
+	cfg := s.m.Config()
+	prevCfg := cfg.Copy()
+	env.Reload()
+	if err := cfg.Reload(); err != nil {
+		env.Restore(prevEnv)
+		return log.Errorf("Reload config: %v", err)
+	}
+	env.SetConfig(cfg.Env())
+	changed := reloadChanges(prevEnv, env.Snapshot())
+	if err := s.reconfigure(changed); err != nil {
+		log.Errorf("Reload failed, rollback: %v", err)
+		cfg.Restore(prevCfg)
+		env.SetConfig(cfg.Env())
+		env.Restore(prevEnv)
+		if rerr := s.reconfigure(changed); rerr != nil {
+			log.Errorf("Reload rollback: %v", rerr)
+		}
+		return err
+	}
+	log.Print("Reload done")
+	return nil
+}
+
+// reconfigure applies the current settings to the changed services. Auth keys
+// are always reloaded, and the console is restarted if the host key changed, as
+// its ssh config is created with it.
+func (s *SRun) reconfigure(changed map[string]bool) error {
+	if changed["log"] {
+		log.Print("Reload log settings...")
+		log.SetMode(env.Get("MB_LOG"))
+		log.SetColors(env.Get("MB_LOG_COLORS"))
+	}
+	hostKey := s.hostKey()
+	if err := s.reloadService("auth", false); err != nil {
+		return err
+	}
+	restartConsole := changed["console"] || changed["auth"]
+	if fp := s.hostKey(); fp != hostKey {
+		log.Printf("Reload host key %s", fp)
+		restartConsole = true
+	}
+	if changed["api"] {
+		if err := s.reloadService("api", true); err != nil {
+			return err
+		}
+	}
+	// console ssh config depends on auth settings too
+	if restartConsole {
+		if err := s.reloadService("console", true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostKey returns the auth host key fingerprint, or an empty string if there
+// is none yet.
+func (s *SRun) hostKey() string {
+	if pk := s.rt.Auth.PublicKey(); pk != nil {
+		return ssh.FingerprintSHA256(pk)
+	}
+	return ""
+}
+
+// reloadService configures the named service again. If restart is true, the
+// service is stopped before and started after that.
+func (s *SRun) reloadService(name string, restart bool) error {
+	svc, found := s.rt.Services.Get(name)
+	if !found {
+		return fmt.Errorf("%s: service not found", name)
+	}
+	log.Printf("Reload %s...", name)
+	if restart {
+		if err := s.stopService(svc); err != nil {
+			return fmt.Errorf("%s stop: %v", name, err)
+		}
+	}
+	if err := svc.Configure(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if restart {
+		if err := s.startService(svc); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/auth"
+	"github.com/munbot/master/testing/assert"
+	"github.com/munbot/master/testing/mock/vfs"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestReloadChanges(t *testing.T) {
+	check := assert.New(t)
+	prev := map[string]string{
+		"MB_LOG":         "info",
+		"MBAPI_PORT":     "6492",
+		"MBCONSOLE_PORT": "6490",
+		"MBAPI_TOKEN":    "t0",
+		"MB_NAME":        "munbot",
+	}
+	cur := map[string]string{
+		"MB_LOG":         "info",
+		"MBAPI_PORT":     "6493",
+		"MBCONSOLE_PORT": "6490",
+		"MBAPI_TOKEN":    "t1",
+		"MB_NAME":        "other",
+	}
+	check.Equal(map[string]bool{"api": true}, reloadChanges(prev, cur))
+	check.Equal(map[string]bool{}, reloadChanges(prev, prev))
+	cur["MB_LOG"] = "debug"
+	cur["MBAUTH_MAX_TRIES"] = "5"
+	check.Equal(map[string]bool{"api": true, "log": true, "auth": true},
+		reloadChanges(prev, cur))
+}
+
+type testMachine struct {
+	Machine
+	cfg *config.Config
+}
+
+func (m *testMachine) Config() *config.Config {
+	return m.cfg
+}
+
+// reloadService records the MBAPI_ADDR value each time it's configured.
+type reloadService struct {
+	testService
+	addr []string
+	fail string
+}
+
+func (s *reloadService) Configure() error {
+	addr := env.Get("MBAPI_ADDR")
+	s.addr = append(s.addr, addr)
+	if addr == s.fail {
+		return errors.New("testing")
+	}
+	return nil
+}
+
+func TestReloadRollback(t *testing.T) {
+	check := require.New(t)
+	prevEnv := env.Snapshot()
+	defer env.Restore(prevEnv)
+	cfg := config.New()
+	prevCfg := cfg.Copy()
+	defer cfg.Restore(prevCfg)
+
+	env.Restore(map[string]string{"MBAPI_ADDR": "127.0.0.2"})
+	check.NoError(config.NewParser(cfg).Set("reload.test", "prev"))
+	authsvc := &reloadService{testService: testService{name: "auth"}}
+	api := &reloadService{testService: testService{name: "api"}, fail: "127.0.0.1"}
+	console := &reloadService{testService: testService{name: "console"}}
+	s := newTestRun(authsvc, api, console)
+	s.m = &testMachine{cfg: cfg}
+	s.rt.Auth = auth.New()
+
+	check.EqualError(s.reload(), "api: testing")
+	check.Equal("127.0.0.2", env.Get("MBAPI_ADDR"), "env restored")
+	check.Equal("prev", config.NewParser(cfg).Map("reload")["reload.test"], "config restored")
+	check.Equal([]string{"127.0.0.1", "127.0.0.2"}, authsvc.addr)
+	check.Equal([]string{"127.0.0.1", "127.0.0.2"}, api.addr, "api reconfigured back")
+	check.Equal(StatusRunning, s.rt.Services.getStatus("api"))
+	check.Nil(console.addr, "console not changed")
+}
+
+func TestReloadConfig(t *testing.T) {
+	check := require.New(t)
+	fs := vfs.NewMockFilesystem("etc/testing/config.json")
+	vfs.SetFilesystem(fs)
+	defer vfs.SetDefaultFilesystem()
+	prevEnv := env.Snapshot()
+	defer env.Restore(prevEnv)
+	defer env.SetConfig(nil)
+	cfg := config.New()
+	prevCfg := cfg.Copy()
+	defer cfg.Restore(prevCfg)
+
+	fh := fs.Add("etc/testing/config.json")
+	fh.WriteString(`{"api":{"port":"7000"}}`)
+	authsvc := &reloadService{testService: testService{name: "auth"}}
+	api := &reloadService{testService: testService{name: "api"}}
+	console := &reloadService{testService: testService{name: "console"}}
+	s := newTestRun(authsvc, api, console)
+	s.m = &testMachine{cfg: cfg}
+	s.rt.Auth = auth.New()
+
+	check.NoError(s.reload())
+	check.Equal("7000", env.Get("MBAPI_PORT"), "config option")
+	check.Len(api.addr, 1, "api reconfigured")
+	check.Nil(console.addr, "console not changed")
+}
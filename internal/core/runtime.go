@@ -17,6 +17,10 @@ type Runtime interface {
 	Configure() error
 	Start() error
 	Stop() error
+	Register(Service, ...string) error
+	SetPolicy(string, Policy) error
+	OnTransition(TransitionFunc)
+	Transitions() []Transition
 }
 
 func (k *Core) Context() context.Context {
@@ -113,3 +117,21 @@ func (k *Core) Stop() error {
 	}
 	return nil
 }
+
+// Register adds a runtime service, which will be started after the deps
+// services. It has to be done before Configure.
+func (k *Core) Register(s Service, deps ...string) error {
+	if k.StateID() != Init {
+		return k.error(ErrRegister)
+	}
+	return k.rt.Services.Register(s, deps...)
+}
+
+// SetPolicy sets the named service restart policy. It has to be done before
+// Configure.
+func (k *Core) SetPolicy(name string, p Policy) error {
+	if k.StateID() != Init {
+		return k.error(ErrRegister)
+	}
+	return k.rt.Services.SetPolicy(name, p)
+}
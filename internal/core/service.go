@@ -0,0 +1,157 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrServiceExists error = errors.New("service already registered")
+var ErrRegister error = errors.New("can not Register services in this state")
+
+// Service is a runtime service managed by the core.
+//
+// Configure is called at Init state, in dependencies order. Start may block
+// until Stop is called, the core runs it in background and waits for Ready
+// before starting the services which depend on it. Stop is called in reverse
+// order, even if the service was not started.
+type Service interface {
+	Name() string
+	Configure() error
+	Start() error
+	Stop() error
+	Ready() bool
+}
+
+type regEntry struct {
+	svc      Service
+	deps     []string
+	policy   Policy
+	status   string
+	since    time.Time
+	err      error
+	restarts int
+	retries  int
+}
+
+// Registry keeps the runtime services and their dependencies.
+type Registry struct {
+	mu  *sync.Mutex
+	idx map[string]*regEntry
+	reg []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:  new(sync.Mutex),
+		idx: make(map[string]*regEntry),
+		reg: make([]string, 0),
+	}
+}
+
+// Register adds a service which requires deps services to be started before
+// it. Dependencies are checked when the order is resolved, so they can be
+// registered later.
+func (r *Registry) Register(s Service, deps ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := s.Name()
+	if name == "" {
+		return errors.New("service without name")
+	}
+	if _, found := r.idx[name]; found {
+		return fmt.Errorf("%s: %v", name, ErrServiceExists)
+	}
+	r.idx[name] = &regEntry{
+		svc:    s,
+		deps:   deps,
+		policy: DefaultPolicy,
+		status: StatusStopped,
+		since:  time.Now(),
+	}
+	r.reg = append(r.reg, name)
+	return nil
+}
+
+// Get returns the named service.
+func (r *Registry) Get(name string) (Service, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, found := r.idx[name]
+	if !found {
+		return nil, false
+	}
+	return e.svc, true
+}
+
+// Len returns the number of registered services.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reg)
+}
+
+// Order returns the services sorted so each one comes after its dependencies.
+// Services without dependencies between them keep their registration order.
+// It fails if a dependency is not registered or if there's a cycle.
+func (r *Registry) Order() ([]Service, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range r.reg {
+		for _, d := range r.idx[name].deps {
+			if _, found := r.idx[d]; !found {
+				return nil, fmt.Errorf("service %s: unknown dependency %s", name, d)
+			}
+		}
+	}
+	l := make([]Service, 0, len(r.reg))
+	done := make(map[string]bool, len(r.reg))
+	for len(l) < len(r.reg) {
+		next := ""
+		for _, name := range r.reg {
+			if !done[name] && r.ready(name, done) {
+				next = name
+				break
+			}
+		}
+		if next == "" {
+			cycle := make([]string, 0)
+			for _, name := range r.reg {
+				if !done[name] {
+					cycle = append(cycle, name)
+				}
+			}
+			sort.Strings(cycle)
+			return nil, fmt.Errorf("services dependency cycle: %s", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		l = append(l, r.idx[next].svc)
+	}
+	return l, nil
+}
+
+func (r *Registry) ready(name string, done map[string]bool) bool {
+	for _, d := range r.idx[name].deps {
+		if !done[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// Ready returns the ready status of each registered service.
+func (r *Registry) Ready() map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := make(map[string]bool, len(r.reg))
+	for name, e := range r.idx {
+		m[name] = e.svc.Ready()
+	}
+	return m
+}
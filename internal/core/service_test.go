@@ -0,0 +1,125 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+type testService struct {
+	name  string
+	ready int32
+	start func(*testService) error
+}
+
+func (s *testService) Name() string     { return s.name }
+func (s *testService) Configure() error { return nil }
+func (s *testService) Stop() error      { return nil }
+func (s *testService) Ready() bool      { return atomic.LoadInt32(&s.ready) == 1 }
+
+func (s *testService) Start() error {
+	if s.start != nil {
+		return s.start(s)
+	}
+	atomic.StoreInt32(&s.ready, 1)
+	return nil
+}
+
+func names(l []Service) []string {
+	n := make([]string, 0, len(l))
+	for _, s := range l {
+		n = append(n, s.Name())
+	}
+	return n
+}
+
+func TestRegistryOrder(t *testing.T) {
+	check := require.New(t)
+	r := NewRegistry()
+	check.NoError(r.Register(&testService{name: "bridge"}, "api", "robot"))
+	check.NoError(r.Register(&testService{name: "api"}, "auth", "robot"))
+	check.NoError(r.Register(&testService{name: "auth"}))
+	check.NoError(r.Register(&testService{name: "robot"}))
+	check.EqualError(r.Register(&testService{name: "api"}), "api: service already registered")
+	check.EqualError(r.Register(&testService{}), "service without name")
+	check.Equal(4, r.Len())
+	l, err := r.Order()
+	check.NoError(err)
+	check.Equal([]string{"auth", "robot", "api", "bridge"}, names(l))
+
+	check.NoError(r.Register(&testService{name: "telemetry"}, "nodb"))
+	_, err = r.Order()
+	check.EqualError(err, "service telemetry: unknown dependency nodb")
+
+	r = NewRegistry()
+	check.NoError(r.Register(&testService{name: "a"}, "b"))
+	check.NoError(r.Register(&testService{name: "b"}, "c"))
+	check.NoError(r.Register(&testService{name: "c"}, "a"))
+	check.NoError(r.Register(&testService{name: "d"}))
+	_, err = r.Order()
+	check.EqualError(err, "services dependency cycle: a, b, c")
+
+	svc, found := r.Get("d")
+	check.True(found)
+	check.Equal("d", svc.Name())
+	_, found = r.Get("e")
+	check.False(found)
+	check.Equal(map[string]bool{"a": false, "b": false, "c": false, "d": false}, r.Ready())
+}
+
+func newTestRun(l ...Service) *SRun {
+	rt := newMem()
+	for _, svc := range l {
+		rt.Services.Register(svc)
+	}
+	return newRun(nil, rt).(*SRun)
+}
+
+func TestStartService(t *testing.T) {
+	check := require.New(t)
+	defer func(d time.Duration) { startTimeout = d }(startTimeout)
+	startTimeout = 50 * time.Millisecond
+	ok := &testService{name: "ok"}
+	fail := &testService{name: "fail", start: func(s *testService) error {
+		return errors.New("testing")
+	}}
+	nilsvc := &testService{name: "nil", start: func(s *testService) error {
+		return nil
+	}}
+	stop := make(chan bool)
+	slow := &testService{name: "slow", start: func(s *testService) error {
+		<-stop
+		return nil
+	}}
+	run := &testService{name: "run", start: func(s *testService) error {
+		atomic.StoreInt32(&s.ready, 1)
+		<-stop
+		return errors.New("run failed")
+	}}
+	s := newTestRun(ok, fail, nilsvc, slow, run)
+	st := s.rt.Services
+
+	check.NoError(s.startService(ok))
+	check.Equal(StatusRunning, st.getStatus("ok"))
+
+	check.EqualError(s.startService(fail), "testing")
+	check.Equal(StatusFailed, st.getStatus("fail"))
+
+	check.EqualError(s.startService(nilsvc), "stopped before ready")
+
+	check.EqualError(s.startService(slow), "not ready after 50ms")
+
+	check.NoError(s.startService(run))
+	check.Equal(StatusRunning, st.getStatus("run"))
+	close(stop)
+	msg := <-s.fail
+	check.Equal("run", msg.name)
+	check.EqualError(msg.err, "run failed")
+	s.wg.Wait()
+}
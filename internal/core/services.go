@@ -0,0 +1,245 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/internal/api"
+	"github.com/munbot/master/internal/api/wapp"
+	"github.com/munbot/master/log"
+	"github.com/munbot/master/robot/master"
+)
+
+var startTimeout time.Duration = 5 * time.Second
+
+// serverPolicy restarts the api and console servers if they fail, without
+// aborting the master robot.
+var serverPolicy Policy = Policy{
+	Restart:    RestartOnFailure,
+	MaxRetries: 5,
+	Backoff:    time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// registerServices adds the core builtin services to the runtime registry.
+func registerServices(m Machine, rt *Mem) error {
+	for _, s := range []struct {
+		svc    Service
+		policy Policy
+		deps   []string
+	}{
+		{&authService{m, rt}, DefaultPolicy, nil},
+		{&robotService{m, rt}, DefaultPolicy, nil},
+		{&apiService{m: m, rt: rt}, serverPolicy, []string{"auth", "robot"}},
+		{&consoleService{rt}, serverPolicy, []string{"auth", "robot"}},
+	} {
+		if err := rt.Services.Register(s.svc, s.deps...); err != nil {
+			return err
+		}
+		if err := rt.Services.SetPolicy(s.svc.Name(), s.policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authService configures the auth manager. It has nothing to run.
+type authService struct {
+	m  Machine
+	rt *Mem
+}
+
+func (s *authService) Name() string {
+	return "auth"
+}
+
+func (s *authService) Configure() error {
+	return s.rt.Auth.Configure(s.m.ConfigFlags().Profile.GetPath("auth"))
+}
+
+func (s *authService) Start() error {
+	return nil
+}
+
+func (s *authService) Stop() error {
+	return nil
+}
+
+func (s *authService) Ready() bool {
+	return true
+}
+
+// robotService manages the master robot.
+type robotService struct {
+	m  Machine
+	rt *Mem
+}
+
+func (s *robotService) Name() string {
+	return "robot"
+}
+
+func (s *robotService) Configure() error {
+	mcfg := &master.Config{
+		Name: env.Get("MUNBOT"),
+	}
+	wappcfg := &wapp.Config{
+		Enable:  env.GetBool("MBAPI"),
+		Debug:   env.GetBool("MBAPI_DEBUG"),
+		Path:    env.Get("MBAPI_PATH"),
+		Runtime: newApiRuntime(s.m, s.rt),
+	}
+	return s.rt.Master.Configure(mcfg, wappcfg)
+}
+
+func (s *robotService) Start() error {
+	return s.rt.Master.Start()
+}
+
+func (s *robotService) Stop() error {
+	if s.rt.Master.Running() {
+		return s.rt.Master.Stop()
+	}
+	return nil
+}
+
+func (s *robotService) Ready() bool {
+	return s.rt.Master.Running()
+}
+
+// apiService manages the api server. Master robot and core handlers are
+// mounted the first time the server is configured as enabled.
+type apiService struct {
+	m       Machine
+	rt      *Mem
+	mounted bool
+}
+
+func (s *apiService) Name() string {
+	return "api"
+}
+
+func (s *apiService) Configure() error {
+	cfg := apiConfig(s.m, s.rt)
+	if err := s.rt.Api.Configure(cfg); err != nil {
+		return err
+	}
+	if cfg.Enable && !s.mounted {
+		p := env.Get("MBAPI_PATH")
+		s.rt.Api.Mount(path.Join(p, "sessions"), api.SessionsHandler(s.rt.Auth))
+		s.rt.Api.Mount(path.Join(p, "logs"), api.LogsHandler())
+		s.rt.Api.Mount(path.Join(p, "events"), api.EventsHandler(s.rt.Master.Events()))
+		s.rt.Api.Mount(p, s.rt.Master)
+		s.mounted = true
+	}
+	return nil
+}
+
+func (s *apiService) Start() error {
+	return s.rt.Api.Start()
+}
+
+func (s *apiService) Stop() error {
+	return s.rt.Api.Stop()
+}
+
+func (s *apiService) Ready() bool {
+	return s.rt.Api.Ready()
+}
+
+// consoleService manages the ssh console server.
+type consoleService struct {
+	rt *Mem
+}
+
+func (s *consoleService) Name() string {
+	return "console"
+}
+
+func (s *consoleService) Configure() error {
+	return s.rt.Console.Configure(consoleConfig(s.rt))
+}
+
+func (s *consoleService) Start() error {
+	return s.rt.Console.Start()
+}
+
+func (s *consoleService) Stop() error {
+	return s.rt.Console.Stop()
+}
+
+func (s *consoleService) Ready() bool {
+	return s.rt.Console.Ready()
+}
+
+// startService runs the service in background and waits for it to be ready.
+// Errors before that are returned, later ones are notified to the supervisor.
+func (s *SRun) startService(svc Service) error {
+	name := svc.Name()
+	s.rt.Services.setStatus(name, StatusStarting, nil)
+	errc := make(chan error, 1)
+	s.wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		defer wg.Done()
+		log.Debugf("%s start...", name)
+		errc <- svc.Start()
+	}(s.wg)
+	timeout := time.After(startTimeout)
+	for !svc.Ready() {
+		select {
+		case err := <-errc:
+			if err == nil && svc.Ready() {
+				// Start does not block
+				s.rt.Services.setStatus(name, StatusRunning, nil)
+				return nil
+			}
+			if err == nil {
+				err = errors.New("stopped before ready")
+			}
+			s.rt.Services.setStatus(name, StatusFailed, err)
+			return err
+		case <-timeout:
+			err := fmt.Errorf("not ready after %s", startTimeout)
+			s.rt.Services.setStatus(name, StatusFailed, err)
+			return err
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	s.rt.Services.setStatus(name, StatusRunning, nil)
+	go s.watch(name, errc)
+	return nil
+}
+
+// watch waits for the service Start to return. If it was not stopped by us,
+// errors are notified as failures. Services which Start does not block are
+// considered running until stopped, unless they should always be restarted.
+func (s *SRun) watch(name string, errc <-chan error) {
+	err := <-errc
+	if s.rt.Services.getStatus(name) != StatusRunning {
+		if err != nil {
+			log.Debugf("%s stopped: %v", name, err)
+		}
+		return
+	}
+	if err == nil {
+		if s.rt.Services.Policy(name).Restart != RestartAlways {
+			return
+		}
+		err = errExited
+	}
+	log.Errorf("%s: %v", name, err)
+	s.notify(failmsg{name, err})
+}
+
+// stopService stops the service, so its exit is not handled as a failure.
+func (s *SRun) stopService(svc Service) error {
+	s.rt.Services.setStatus(svc.Name(), StatusStopped, nil)
+	return svc.Stop()
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/munbot/master/env"
+	"github.com/munbot/master/log"
+)
+
+var ErrStopTimeout error = errors.New("stop timeout")
+
+// osExit is called to force the exit if a second signal is received while
+// shutting down.
+var osExit func(int) = os.Exit
+
+const forceExitStatus int = 130
+
+// stopResult is a component shutdown result.
+type stopResult struct {
+	name    string
+	err     error
+	timeout bool
+	took    time.Duration
+}
+
+// shutdown stops the runtime components with a total deadline for all of
+// them.
+type shutdown struct {
+	timeout  time.Duration
+	deadline time.Time
+	report   []*stopResult
+}
+
+func newShutdown(timeout time.Duration) *shutdown {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &shutdown{
+		timeout:  timeout,
+		deadline: time.Now().Add(timeout),
+		report:   make([]*stopResult, 0),
+	}
+}
+
+// stopTimeout returns the configured shutdown timeout.
+func stopTimeout() time.Duration {
+	return env.GetDuration("MB_STOP_TIMEOUT")
+}
+
+// stop runs the component stop function and waits for it until the shutdown
+// deadline. Once the deadline is reached, the function is left running in
+// background and the component is reported as timed out.
+func (d *shutdown) stop(name string, fn func() error) error {
+	r := &stopResult{name: name}
+	d.report = append(d.report, r)
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fn()
+	}()
+	select {
+	case r.err = <-errc:
+	case <-time.After(time.Until(d.deadline)):
+		r.timeout = true
+		r.err = fmt.Errorf("%s: %v after %s", name, ErrStopTimeout, d.timeout)
+	}
+	r.took = time.Since(start)
+	return r.err
+}
+
+// timedOut returns the names of the components which did not stop in time.
+func (d *shutdown) timedOut() []string {
+	l := make([]string, 0)
+	for _, r := range d.report {
+		if r.timeout {
+			l = append(l, r.name)
+		}
+	}
+	return l
+}
+
+// Report logs the shutdown results. If any of the components timed out, the
+// returned error names them.
+func (d *shutdown) Report() error {
+	for _, r := range d.report {
+		status := "ok"
+		if r.timeout {
+			status = "timeout"
+		} else if r.err != nil {
+			status = "error: " + r.err.Error()
+		}
+		log.Infof("Stop %s: %s (%s)", r.name, status, r.took)
+	}
+	if l := d.timedOut(); len(l) > 0 {
+		return fmt.Errorf("%w: %s", ErrStopTimeout, strings.Join(l, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestShutdown(t *testing.T) {
+	check := require.New(t)
+	d := newShutdown(50 * time.Millisecond)
+	check.NoError(d.stop("ok", func() error { return nil }))
+	check.EqualError(d.stop("fail", func() error { return errors.New("testing") }), "testing")
+	check.NoError(d.Report())
+
+	block := make(chan bool)
+	defer close(block)
+	err := d.stop("slow", func() error {
+		<-block
+		return nil
+	})
+	check.EqualError(err, "slow: stop timeout after 50ms")
+	check.Error(d.stop("late", func() error {
+		<-block
+		return nil
+	}))
+	check.Equal([]string{"slow", "late"}, d.timedOut())
+	err = d.Report()
+	check.True(errors.Is(err, ErrStopTimeout))
+	check.EqualError(err, "stop timeout: slow, late")
+}
+
+func TestForceExit(t *testing.T) {
+	check := require.New(t)
+	defer func(fn func(int)) { osExit = fn }(osExit)
+	status := make(chan int, 1)
+	osExit = func(st int) { status <- st }
+	s := newRun(nil, nil).(*SRun)
+
+	done := make(chan bool)
+	close(done)
+	s.forceExit(done)
+	check.Len(status, 0)
+
+	s.osint <- syscall.SIGTERM
+	s.forceExit(make(chan bool))
+	check.Equal(forceExitStatus, <-status)
+}
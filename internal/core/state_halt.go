@@ -40,6 +40,12 @@ func (s *SHalt) Stop() error {
 
 func (s *SHalt) Halt() error {
 	log.Print("Halt...")
+	if s.rt.pid != nil {
+		if err := s.rt.pid.Unlock(); err != nil {
+			log.Errorf("profile unlock: %v", err)
+		}
+		s.rt.pid = nil
+	}
 	log.Infof("Uptime %s", s.rt.Master.Uptime())
 	log.Info("Bye!")
 	return nil
@@ -7,11 +7,11 @@ import (
 	"github.com/munbot/master/config"
 	"github.com/munbot/master/env"
 	"github.com/munbot/master/internal/api"
-	"github.com/munbot/master/internal/api/wapp"
 	"github.com/munbot/master/internal/auth"
 	"github.com/munbot/master/internal/console"
 	"github.com/munbot/master/log"
 	"github.com/munbot/master/robot/master"
+	"github.com/munbot/master/utils/pidfile"
 )
 
 var _ State = &SInit{}
@@ -32,11 +32,29 @@ func (s *SInit) Init() error {
 	if err := cfg.Load(); err != nil {
 		return log.Error(err)
 	}
+	// config options are used for the settings not set from the env
+	env.SetConfig(cfg.Env())
+	log.SetMode(env.Get("MB_LOG"))
+	log.SetColors(env.Get("MB_LOG_COLORS"))
+	log.SetPrefix(env.Get("MUNBOT"))
 	cfl := s.m.ConfigFlags()
 	log.Print("Init profile setup...")
 	if err := cfl.Profile.Setup(); err != nil {
 		return log.Error(err)
 	}
+	if s.rt.pid == nil {
+		log.Print("Init profile lock...")
+		f, err := pidfile.Lock(cfl.Profile.GetRundirPath("master.pid"))
+		if err != nil {
+			return log.Errorf("profile %s: %v", cfl.Profile.Name, err)
+		}
+		s.rt.pid = f
+	}
+	if s.rt.sockets == nil {
+		if err := sdSockets(s.rt); err != nil {
+			return log.Error(err)
+		}
+	}
 	if s.rt.Master == nil {
 		log.Print("Init auth manager...")
 		s.rt.Auth = auth.New()
@@ -46,60 +64,61 @@ func (s *SInit) Init() error {
 		s.rt.Api = api.New()
 		log.Print("Init master console...")
 		s.rt.Console = console.New()
+		if err := registerServices(s.m, s.rt); err != nil {
+			return log.Error(err)
+		}
 	}
 	return nil
 }
 
 func (s *SInit) Configure() error {
 	log.Debug("configure...")
-	//~ cfg := s.m.Config()
-	cfl := s.m.ConfigFlags()
-
-	log.Print("Configure auth manager...")
-	cadir := cfl.Profile.GetPath("auth")
-	if err := s.rt.Auth.Configure(cadir); err != nil {
-		return err
-	}
-
-	log.Print("Configure master robot...")
-	mcfg := &master.Config{
-		Name: env.Get("MUNBOT"),
-	}
-	wappcfg := &wapp.Config{
-		Enable: env.GetBool("MBAPI"),
-		Debug:  env.GetBool("MBAPI_DEBUG"),
-		Path:   env.Get("MBAPI_PATH"),
-	}
-	if err := s.rt.Master.Configure(mcfg, wappcfg); err != nil {
+	l, err := s.rt.Services.Order()
+	if err != nil {
 		return log.Error(err)
 	}
+	for _, svc := range l {
+		log.Printf("Configure %s...", svc.Name())
+		if err := svc.Configure(); err != nil {
+			return log.Errorf("%s: %v", svc.Name(), err)
+		}
+	}
+
+	log.Print("Configure metrics...")
+	registerMetrics(s.m, s.rt)
 
-	log.Print("Configure master api...")
-	apiEnable := env.GetBool("MBAPI")
-	apiCfg := &api.ServerConfig{
-		Enable: apiEnable,
+	return s.m.SetState(Run, "configured")
+}
+
+func apiConfig(m Machine, rt *Mem) *api.ServerConfig {
+	return &api.ServerConfig{
+		Enable: env.GetBool("MBAPI"),
+		Net:    env.Get("MBAPI_NET"),
 		Addr:   env.Get("MBAPI_ADDR"),
 		Port:   env.GetUint("MBAPI_PORT"),
+		Auth:   rt.Auth,
+
+		TLS:         env.GetBool("MBAPI_TLS"),
+		TLSCert:     env.Get("MBAPI_TLS_CERT"),
+		TLSKey:      env.Get("MBAPI_TLS_KEY"),
+		TLSClients:  env.GetBool("MBAPI_TLS_CLIENTS"),
+		TLSClientCA: env.Get("MBAPI_TLS_CLIENT_CA"),
+		TLSRoles:    env.Get("MBAPI_TLS_CLIENT_ROLES"),
+		Health:      m,
+		ListenFile:  rt.sockets["api"],
 	}
-	if err := s.rt.Api.Configure(apiCfg); err != nil {
-		return log.Error(err)
-	}
-	if apiEnable {
-		s.rt.Api.Mount(env.Get("MBAPI_PATH"), s.rt.Master)
-	}
+}
 
-	log.Print("Configure master console...")
-	consCfg := &console.Config{
+func consoleConfig(rt *Mem) *console.Config {
+	return &console.Config{
 		Enable: env.GetBool("MBCONSOLE"),
 		Addr:   env.Get("MBCONSOLE_ADDR"),
 		Port:   env.GetUint("MBCONSOLE_PORT"),
-		Auth:   s.rt.Auth,
-	}
-	if err := s.rt.Console.Configure(consCfg); err != nil {
-		return log.Error(err)
-	}
+		Auth:   rt.Auth,
+		Master: rt.Master,
 
-	return s.m.SetState(Run)
+		ListenFile: rt.sockets["console"],
+	}
 }
 
 func (s *SInit) Start() error {
@@ -4,11 +4,14 @@
 package core
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/munbot/master/internal/systemd"
 	"github.com/munbot/master/log"
 )
 
@@ -27,6 +30,11 @@ type SRun struct {
 	wait  time.Duration
 	exit  chan bool
 	osint chan os.Signal
+	oshup chan os.Signal
+	relc  chan bool
+	stopc chan bool
+	cause string
+	wdog  *watchdog
 }
 
 func newRun(m Machine, rt *Mem) State {
@@ -38,6 +46,9 @@ func newRun(m Machine, rt *Mem) State {
 		wait:  300 * time.Millisecond,
 		exit:  make(chan bool, 1),
 		osint: make(chan os.Signal, 1),
+		oshup: make(chan os.Signal, 1),
+		relc:  make(chan bool, 1),
+		stopc: make(chan bool),
 	}
 }
 
@@ -51,42 +62,34 @@ func (s *SRun) Configure() error {
 
 func (s *SRun) Start() error {
 	log.Print("Start...")
-	// start master robot
-	log.Print("Start master robot...")
 	s.rt.Master.ExitNotify(s.exit)
-	s.wg.Add(1)
-	go func(wg *sync.WaitGroup, fail chan failmsg) {
-		defer wg.Done()
-		log.Debug("robot start...")
-		if err := s.rt.Master.Start(); err != nil {
-			log.Error(err)
-			fail <- failmsg{"robot", err}
-		}
-	}(s.wg, s.fail)
-	// start api server
-	time.Sleep(s.wait)
-	log.Print("Start master api...")
-	s.wg.Add(1)
-	go func(wg *sync.WaitGroup, fail chan failmsg) {
-		defer wg.Done()
-		log.Debug("api start...")
-		if err := s.rt.Api.Start(); err != nil {
-			log.Error(err)
-			fail <- failmsg{"api", err}
-		}
-	}(s.wg, s.fail)
-	// start console server
-	time.Sleep(s.wait)
-	log.Print("Start master console...")
-	s.wg.Add(1)
-	go func(wg *sync.WaitGroup, fail chan failmsg) {
-		defer wg.Done()
-		log.Debug("console start...")
-		if err := s.rt.Console.Start(); err != nil {
-			log.Error(err)
-			fail <- failmsg{"console", err}
+	s.rt.Master.ReloadNotify(s.relc)
+	l, err := s.rt.Services.Order()
+	if err != nil {
+		return log.Error(err)
+	}
+	abort := false
+	for _, svc := range l {
+		name := svc.Name()
+		log.Printf("Start %s...", name)
+		if err := s.startService(svc); err != nil {
+			log.Errorf("%s: %v", name, err)
+			if s.supervise(failmsg{name, err}) {
+				abort = true
+				// let Run abort and stop the ones already started
+				select {
+				case s.fail <- failmsg{name, fmt.Errorf("%s: %v", name, err)}:
+				default:
+					log.Debug("there was a failure already")
+				}
+				break
+			}
 		}
-	}(s.wg, s.fail)
+	}
+	if !abort {
+		sdNotify(systemd.Ready, systemd.Status(readyStatus(s.rt.Services)))
+	}
+	s.wdog = newWatchdog()
 	return nil
 }
 
@@ -94,25 +97,43 @@ func (s *SRun) Run() error {
 	log.Print("Run...")
 	var fail failmsg
 	abort := false
-	signal.Notify(s.osint, os.Interrupt)
+	signal.Notify(s.osint, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	defer signal.Stop(s.osint)
+	signal.Notify(s.oshup, syscall.SIGHUP)
+	defer signal.Stop(s.oshup)
 LOOP:
 	for {
 		select {
 		case fail = <-s.fail:
+			if !s.supervise(fail) {
+				fail = failmsg{}
+				continue
+			}
 			log.Info("fail...")
+			s.cause = fmt.Sprintf("%s failed", fail.name)
 			break LOOP
 		case <-s.exit:
 			log.Info("master exit...")
+			s.cause = "master exit"
 			abort = true
 			break LOOP
-		case <-s.osint:
-			log.Info("os interrupt...")
+		case sig := <-s.osint:
+			log.Infof("os %s...", sig)
+			s.cause = fmt.Sprintf("os %s", sig)
 			abort = true
 			break LOOP
+		case <-s.oshup:
+			log.Info("os hangup...")
+			s.reload()
+		case <-s.relc:
+			log.Info("master reload...")
+			s.reload()
 		default:
 			time.Sleep(s.wait)
+			s.wdog.ping()
 			if !s.rt.Master.Running() {
 				log.Info("master robot is not running...")
+				s.cause = "master robot is not running"
 				select {
 				case fail = <-s.fail:
 					log.Debug("there was a failure before abort")
@@ -123,15 +144,15 @@ LOOP:
 			}
 		}
 	}
-	// close fail channel just in case...
-	// maybe not a good idea? but at least it makes them panic...
-	defer close(s.fail)
 	if fail.err != nil {
 		log.Debugf("FAIL: core %s: %s", fail.name, fail.err)
 		abort = true
 	}
 	if abort {
 		log.Debug("ABORT!")
+		done := make(chan bool)
+		defer close(done)
+		go s.forceExit(done)
 		if err := s.m.Abort(); err != nil {
 			return err
 		}
@@ -139,33 +160,57 @@ LOOP:
 	return fail.err
 }
 
+// forceExit exits right away if a signal is received while shutting down,
+// before the done channel is closed.
+func (s *SRun) forceExit(done <-chan bool) {
+	select {
+	case sig := <-s.osint:
+		log.Errorf("os %s while shutting down, forced exit!", sig)
+		osExit(forceExitStatus)
+	case <-done:
+	}
+}
+
 func (s *SRun) Stop() error {
 	log.Print("Stop...")
+	sdNotify(systemd.Stopping)
+	s.stopping()
 	var xerr error
-	// stop console
-	log.Print("Stop master console...")
-	if err := s.rt.Console.Stop(); err != nil {
-		xerr = log.Error(err)
-	}
-	// stop api
-	log.Print("Stop master api...")
-	if err := s.rt.Api.Stop(); err != nil {
-		xerr = log.Error(err)
+	d := newShutdown(stopTimeout())
+	log.Debugf("shutdown timeout in %s", d.timeout)
+	l, err := s.rt.Services.Order()
+	if err != nil {
+		return log.Error(err)
 	}
-	// stop robot
-	if s.rt.Master.Running() {
-		log.Print("Stop master robot...")
-		if err := s.rt.Master.Stop(); err != nil {
+	// stop them in reverse order
+	for i := len(l) - 1; i >= 0; i-- {
+		svc := l[i]
+		log.Printf("Stop %s...", svc.Name())
+		if err := d.stop(svc.Name(), func() error { return s.stopService(svc) }); err != nil {
 			xerr = log.Error(err)
 		}
 	}
 	// wait for them...
 	log.Debug("wait for them to finish...")
-	s.wg.Wait()
+	if err := d.stop("wait", s.wgWait); err != nil {
+		xerr = log.Error(err)
+	}
+	if err := d.Report(); err != nil {
+		return log.Error(err)
+	}
 	if xerr != nil {
 		return xerr
 	}
-	return s.m.SetState(Halt)
+	cause := s.cause
+	if cause == "" {
+		cause = "stop"
+	}
+	return s.m.SetState(Halt, cause)
+}
+
+func (s *SRun) wgWait() error {
+	s.wg.Wait()
+	return nil
 }
 
 func (s *SRun) Halt() error {
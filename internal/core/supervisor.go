@@ -0,0 +1,255 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/munbot/master/log"
+)
+
+// Services status.
+const (
+	StatusStarting string = "starting"
+	StatusRunning  string = "running"
+	StatusBackoff  string = "backing-off"
+	StatusFailed   string = "failed"
+	StatusStopped  string = "stopped"
+)
+
+var errExited error = errors.New("exited")
+
+// RestartMode sets when a service is restarted.
+type RestartMode int
+
+const (
+	// RestartNever does not restart the service.
+	RestartNever RestartMode = iota
+	// RestartOnFailure restarts the service if it returns an error.
+	RestartOnFailure
+	// RestartAlways restarts the service whenever it exits.
+	RestartAlways
+)
+
+var restartModeName map[RestartMode]string = map[RestartMode]string{
+	RestartNever:     "never",
+	RestartOnFailure: "on-failure",
+	RestartAlways:    "always",
+}
+
+func (m RestartMode) String() string {
+	if n, ok := restartModeName[m]; ok {
+		return n
+	}
+	return fmt.Sprintf("RestartMode(%d)", m)
+}
+
+// Policy is a service restart policy. Restarts are delayed by Backoff, which
+// is doubled on each retry up to MaxBackoff. Retries are counted since the
+// last time the service was running for MaxBackoff at least. A MaxRetries
+// value of 0 means no limit.
+//
+// If a Critical service fails and it's not restarted, the core aborts.
+type Policy struct {
+	Restart    RestartMode
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Critical   bool
+}
+
+// DefaultPolicy is used for services without a policy set. They are not
+// restarted and their failure aborts the core.
+var DefaultPolicy Policy = Policy{
+	Restart:    RestartNever,
+	Backoff:    time.Second,
+	MaxBackoff: 30 * time.Second,
+	Critical:   true,
+}
+
+// retry checks if the service should be restarted after the given retries.
+func (p Policy) retry(err error, retries int) bool {
+	switch p.Restart {
+	case RestartOnFailure:
+		if err == nil || err == errExited {
+			return false
+		}
+	case RestartAlways:
+	default:
+		return false
+	}
+	return p.MaxRetries == 0 || retries < p.MaxRetries
+}
+
+// delay returns the backoff time for the given retry.
+func (p Policy) delay(retries int) time.Duration {
+	d := p.Backoff
+	if d <= 0 {
+		d = time.Second
+	}
+	for i := 0; i < retries; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// ServiceStatus is a runtime service status information.
+type ServiceStatus struct {
+	Name     string
+	Status   string
+	Restarts int
+	Since    time.Time
+	Err      error
+}
+
+// SetPolicy sets the named service restart policy.
+func (r *Registry) SetPolicy(name string, p Policy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, found := r.idx[name]
+	if !found {
+		return fmt.Errorf("%s: service not found", name)
+	}
+	e.policy = p
+	return nil
+}
+
+// Policy returns the named service restart policy.
+func (r *Registry) Policy(name string) Policy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, found := r.idx[name]; found {
+		return e.policy
+	}
+	return DefaultPolicy
+}
+
+func (r *Registry) setStatus(name, status string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, found := r.idx[name]; found {
+		e.status = status
+		e.since = time.Now()
+		e.err = err
+	}
+}
+
+func (r *Registry) getStatus(name string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, found := r.idx[name]; found {
+		return e.status
+	}
+	return ""
+}
+
+// Status returns the services status, in registration order.
+func (r *Registry) Status() []*ServiceStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l := make([]*ServiceStatus, 0, len(r.reg))
+	for _, name := range r.reg {
+		e := r.idx[name]
+		l = append(l, &ServiceStatus{
+			Name:     name,
+			Status:   e.status,
+			Restarts: e.restarts,
+			Since:    e.since,
+			Err:      e.err,
+		})
+	}
+	return l
+}
+
+// failed updates the status of the failed service and returns the restart
+// delay, if it has to be restarted.
+func (r *Registry) failed(name string, err error) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, found := r.idx[name]
+	if !found {
+		return 0, false
+	}
+	p := e.policy
+	if e.status == StatusRunning && time.Since(e.since) >= p.MaxBackoff {
+		e.retries = 0
+	}
+	e.since = time.Now()
+	e.err = err
+	if !p.retry(err, e.retries) {
+		e.status = StatusFailed
+		return 0, false
+	}
+	d := p.delay(e.retries)
+	e.status = StatusBackoff
+	e.retries++
+	e.restarts++
+	return d, true
+}
+
+// supervise handles a service failure, restarting it according to its policy.
+// It returns true if the core has to abort.
+func (s *SRun) supervise(msg failmsg) bool {
+	delay, restart := s.rt.Services.failed(msg.name, msg.err)
+	if restart {
+		log.Warnf("Service %s: %v, restart in %s", msg.name, msg.err, delay)
+		go s.restartService(msg.name, delay)
+		return false
+	}
+	if s.rt.Services.Policy(msg.name).Critical {
+		return true
+	}
+	log.Errorf("Service %s failed: %v", msg.name, msg.err)
+	return false
+}
+
+// restartService starts the named service again after delay, unless the core
+// is stopping.
+func (s *SRun) restartService(name string, delay time.Duration) {
+	select {
+	case <-s.stopc:
+		return
+	case <-time.After(delay):
+	}
+	svc, found := s.rt.Services.Get(name)
+	if !found {
+		return
+	}
+	log.Printf("Restart %s...", name)
+	if err := s.startService(svc); err != nil {
+		s.notify(failmsg{name, err})
+	}
+}
+
+// notify sends the failure to the Run loop, unless the core is stopping.
+func (s *SRun) notify(msg failmsg) {
+	select {
+	case <-s.stopc:
+		log.Debugf("%s failed while stopping: %v", msg.name, msg.err)
+		return
+	default:
+	}
+	select {
+	case <-s.stopc:
+		log.Debugf("%s failed while stopping: %v", msg.name, msg.err)
+	case s.fail <- msg:
+	}
+}
+
+// stopping closes the stop channel, so failures are not handled anymore.
+func (s *SRun) stopping() {
+	select {
+	case <-s.stopc:
+	default:
+		close(s.stopc)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestPolicy(t *testing.T) {
+	check := require.New(t)
+	err := errors.New("testing")
+	p := Policy{Restart: RestartNever}
+	check.False(p.retry(err, 0))
+
+	p = Policy{Restart: RestartOnFailure, MaxRetries: 2}
+	check.True(p.retry(err, 0))
+	check.True(p.retry(err, 1))
+	check.False(p.retry(err, 2))
+	check.False(p.retry(errExited, 0))
+	check.False(p.retry(nil, 0))
+
+	p = Policy{Restart: RestartAlways}
+	check.True(p.retry(errExited, 100))
+
+	p = Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	check.Equal(time.Second, p.delay(0))
+	check.Equal(2*time.Second, p.delay(1))
+	check.Equal(4*time.Second, p.delay(2))
+	check.Equal(5*time.Second, p.delay(3))
+	check.Equal(5*time.Second, p.delay(30))
+
+	check.Equal("on-failure", RestartOnFailure.String())
+	check.Equal("RestartMode(9)", RestartMode(9).String())
+}
+
+func TestSupervise(t *testing.T) {
+	check := require.New(t)
+	fails := int32(2)
+	svc := &testService{name: "console", start: func(s *testService) error {
+		if atomic.AddInt32(&fails, -1) >= 0 {
+			return errors.New("listen")
+		}
+		atomic.StoreInt32(&s.ready, 1)
+		return nil
+	}}
+	robot := &testService{name: "robot"}
+	s := newTestRun(svc, robot)
+	st := s.rt.Services
+	check.NoError(st.SetPolicy("console", Policy{
+		Restart:    RestartOnFailure,
+		MaxRetries: 3,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	}))
+	check.Error(st.SetPolicy("nosvc", DefaultPolicy))
+
+	err := s.startService(svc)
+	check.EqualError(err, "listen")
+	check.False(s.supervise(failmsg{"console", err}))
+	check.Equal(StatusBackoff, st.getStatus("console"))
+	// the restart fails again and it's notified
+	msg := <-s.fail
+	check.False(s.supervise(msg))
+	// and the next one works
+	for i := 0; i < 100 && st.getStatus("console") != StatusRunning; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	l := st.Status()
+	check.Equal("console", l[0].Name)
+	check.Equal(StatusRunning, l[0].Status)
+	check.Equal(2, l[0].Restarts)
+
+	// retries exhausted
+	for i := 0; i < 3; i++ {
+		st.failed("console", errors.New("testing"))
+	}
+	check.False(s.supervise(failmsg{"console", errors.New("testing")}))
+	check.Equal(StatusFailed, st.getStatus("console"))
+
+	// critical services abort
+	check.True(s.supervise(failmsg{"robot", errors.New("testing")}))
+	check.Equal(StatusFailed, st.getStatus("robot"))
+
+	// failures are not handled while stopping
+	s.stopping()
+	s.stopping()
+	s.notify(failmsg{"console", errors.New("testing")})
+	check.Len(s.fail, 0)
+	s.wg.Wait()
+}
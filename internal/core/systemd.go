@@ -0,0 +1,93 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/munbot/master/internal/systemd"
+	"github.com/munbot/master/log"
+)
+
+// sdNotify sends the states to the service manager, if there is one.
+func sdNotify(states ...string) {
+	if _, err := systemd.Notify(states...); err != nil {
+		log.Debugf("systemd notify: %v", err)
+	}
+}
+
+// sdStatus reports the state changes to the service manager.
+func (k *Core) sdStatus(from, to StateID) {
+	if !systemd.Enabled() {
+		return
+	}
+	msg := StateName(to)
+	if l := k.Transitions(); len(l) > 0 && l[len(l)-1].Cause != "" {
+		msg = fmt.Sprintf("%s: %s", msg, l[len(l)-1].Cause)
+	}
+	sdNotify(systemd.Status(msg))
+}
+
+// readyStatus returns the status message once the services were started,
+// listing the ones which are not running.
+func readyStatus(r *Registry) string {
+	l := make([]string, 0)
+	for _, st := range r.Status() {
+		if st.Status != StatusRunning {
+			l = append(l, fmt.Sprintf("%s %s", st.Name, st.Status))
+		}
+	}
+	if len(l) == 0 {
+		return "Ready"
+	}
+	return "Ready, " + strings.Join(l, ", ")
+}
+
+// sdSockets gets the sockets passed by the service manager.
+func sdSockets(rt *Mem) error {
+	files, err := systemd.Files()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		log.Printf("Socket activation %s", n)
+	}
+	rt.sockets = files
+	return nil
+}
+
+// watchdog pings the service manager at half its timeout, if it's enabled.
+type watchdog struct {
+	every time.Duration
+	last  time.Time
+}
+
+func newWatchdog() *watchdog {
+	d, err := systemd.WatchdogInterval()
+	if err != nil {
+		log.Error(err)
+	}
+	w := &watchdog{every: d / 2}
+	if w.every > 0 {
+		log.Debugf("systemd watchdog every %s", w.every)
+	}
+	return w
+}
+
+// ping notifies the service manager if it's time to do it.
+func (w *watchdog) ping() {
+	if w == nil || w.every <= 0 || time.Since(w.last) < w.every {
+		return
+	}
+	w.last = time.Now()
+	sdNotify(systemd.Watchdog)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestReadyStatus(t *testing.T) {
+	check := require.New(t)
+	r := NewRegistry()
+	check.NoError(r.Register(&testService{name: "auth"}))
+	check.NoError(r.Register(&testService{name: "console"}))
+	r.setStatus("auth", StatusRunning, nil)
+	r.setStatus("console", StatusRunning, nil)
+	check.Equal("Ready", readyStatus(r))
+	r.setStatus("console", StatusBackoff, nil)
+	check.Equal("Ready, console backing-off", readyStatus(r))
+}
+
+func TestWatchdog(t *testing.T) {
+	check := require.New(t)
+	var w *watchdog
+	w.ping()
+
+	defer os.Unsetenv("WATCHDOG_USEC")
+	os.Unsetenv("WATCHDOG_USEC")
+	w = newWatchdog()
+	check.Equal(time.Duration(0), w.every)
+	w.ping()
+	check.True(w.last.IsZero())
+
+	os.Setenv("WATCHDOG_USEC", "2000000")
+	w = newWatchdog()
+	check.Equal(time.Second, w.every)
+	w.ping()
+	last := w.last
+	check.False(last.IsZero())
+	w.ping()
+	check.Equal(last, w.last)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/munbot/master/log"
+)
+
+var ErrInvalidState error = errors.New("invalid state")
+var ErrTransition error = errors.New("illegal transition")
+
+// TransitionError is returned when a state change is rejected.
+type TransitionError struct {
+	From StateID
+	To   StateID
+	Err  error
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("core: %v %s -> %s", e.Err, StateName(e.From), StateName(e.To))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// transitions are the allowed state changes.
+var transitions map[StateID][]StateID = map[StateID][]StateID{
+	Dead: {Init},
+	Init: {Run, Halt},
+	Run:  {Halt},
+}
+
+func checkTransition(from, to StateID) error {
+	if _, ok := sidMap[to]; !ok {
+		return &TransitionError{From: from, To: to, Err: ErrInvalidState}
+	}
+	for _, s := range transitions[from] {
+		if s == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to, Err: ErrTransition}
+}
+
+// Transition is a state change record.
+type Transition struct {
+	From  StateID
+	To    StateID
+	Time  time.Time
+	Cause string
+}
+
+// historySize is the number of transitions kept.
+var historySize int = 32
+
+// TransitionFunc is called on each state change.
+type TransitionFunc func(from, to StateID)
+
+// OnTransition adds fn to be called after each state change.
+func (k *Core) OnTransition(fn TransitionFunc) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.onTrans = append(k.onTrans, fn)
+}
+
+// Transitions returns the state changes history, oldest first.
+func (k *Core) Transitions() []Transition {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	l := make([]Transition, len(k.history))
+	copy(l, k.history)
+	return l
+}
+
+func (k *Core) stateFor(s StateID) State {
+	switch s {
+	case Init:
+		return k.sInit
+	case Run:
+		return k.sRun
+	case Halt:
+		return k.sHalt
+	}
+	return nil
+}
+
+// transition changes the current state id if allowed, records it and calls
+// the subscribers.
+func (k *Core) transition(to StateID, cause string) error {
+	k.mu.Lock()
+	from := k.stid
+	if err := checkTransition(from, to); err != nil {
+		k.mu.Unlock()
+		return err
+	}
+	k.stid = to
+	k.state = k.stateFor(to)
+	k.history = append(k.history, Transition{
+		From:  from,
+		To:    to,
+		Time:  time.Now(),
+		Cause: cause,
+	})
+	if n := len(k.history) - historySize; n > 0 {
+		k.history = k.history[n:]
+	}
+	subs := make([]TransitionFunc, len(k.onTrans))
+	copy(subs, k.onTrans)
+	k.mu.Unlock()
+	log.Debugf("transition %s -> %s: %s", StateName(from), StateName(to), cause)
+	if k.rt.Master != nil {
+		k.rt.Master.CurrentState(StateName(to))
+	}
+	for _, fn := range subs {
+		fn(from, to)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestCheckTransition(t *testing.T) {
+	check := require.New(t)
+	check.NoError(checkTransition(Dead, Init))
+	check.NoError(checkTransition(Init, Run))
+	check.NoError(checkTransition(Run, Halt))
+
+	err := checkTransition(Run, Init)
+	check.EqualError(err, "core: illegal transition Run -> Init")
+	check.True(errors.Is(err, ErrTransition))
+	var terr *TransitionError
+	check.True(errors.As(err, &terr))
+	check.Equal(Run, terr.From)
+	check.Equal(Init, terr.To)
+
+	check.True(errors.Is(checkTransition(Halt, Run), ErrTransition))
+	check.True(errors.Is(checkTransition(Run, Run), ErrTransition))
+	check.True(errors.Is(checkTransition(Run, StateID(9)), ErrInvalidState))
+}
+
+func TestTransitions(t *testing.T) {
+	check := require.New(t)
+	k := New(newMem())
+	check.Equal(Init, k.StateID())
+	check.Equal(k.sInit, k.state)
+
+	calls := make([][2]StateID, 0)
+	k.OnTransition(func(from, to StateID) {
+		calls = append(calls, [2]StateID{from, to})
+		check.Equal(to, k.StateID(), "state set before callback")
+	})
+
+	err := k.SetState(StateID(9), "testing")
+	check.True(errors.Is(err, ErrInvalidState))
+	check.Equal(Init, k.StateID(), "invalid state not assigned")
+	check.True(errors.Is(k.SetState(Init, "testing"), ErrTransition))
+
+	check.NoError(k.SetState(Run, "configured"))
+	check.Equal(k.sRun, k.state)
+	check.NoError(k.SetState(Halt, "os terminated"))
+	check.Equal(k.sHalt, k.state)
+	check.Equal([][2]StateID{{Init, Run}, {Run, Halt}}, calls)
+
+	h := k.Transitions()
+	check.Len(h, 3)
+	check.Equal(Transition{From: Dead, To: Init, Time: h[0].Time, Cause: "new"}, h[0])
+	check.Equal("configured", h[1].Cause)
+	check.Equal(Halt, h[2].To)
+	check.Equal("os terminated", h[2].Cause)
+	check.False(h[2].Time.Before(h[1].Time))
+}
+
+func TestTransitionsHistorySize(t *testing.T) {
+	check := require.New(t)
+	defer func(n int) { historySize = n }(historySize)
+	historySize = 2
+	k := New(newMem())
+	check.NoError(k.SetState(Run, "configured"))
+	check.NoError(k.SetState(Halt, "stop"))
+	h := k.Transitions()
+	check.Len(h, 2)
+	check.Equal(Init, h[0].From)
+	check.Equal(Run, h[1].From)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package event multiplexes gobot robots devices events.
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/log"
+)
+
+// Event is a device event, as published by its driver.
+type Event struct {
+	ID     uint64      `json:"id"`
+	Time   time.Time   `json:"time"`
+	Robot  string      `json:"robot"`
+	Device string      `json:"device"`
+	Name   string      `json:"event"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// JSON encodes the event. If its data can not be json encoded its string
+// representation is used instead.
+func (ev *Event) JSON() []byte {
+	blob, err := json.Marshal(ev)
+	if err != nil {
+		e := *ev
+		e.Data = fmt.Sprintf("%v", ev.Data)
+		blob, _ = json.Marshal(&e)
+	}
+	return blob
+}
+
+// Filter selects events by robot, device and event name. Empty fields match
+// any value.
+type Filter struct {
+	Robot  string
+	Device string
+	Event  string
+}
+
+// Match checks if the event is selected by the filter.
+func (f *Filter) Match(ev *Event) bool {
+	if f == nil {
+		return true
+	}
+	return (f.Robot == "" || f.Robot == ev.Robot) &&
+		(f.Device == "" || f.Device == ev.Device) &&
+		(f.Event == "" || f.Event == ev.Name)
+}
+
+// Sub is an events subscription.
+type Sub struct {
+	C      <-chan *Event
+	c      chan *Event
+	filter *Filter
+}
+
+// Hub keeps the last published events and sends new ones to its subscribers.
+type Hub struct {
+	mu      sync.Mutex
+	seq     uint64
+	size    int
+	last    []*Event
+	subs    map[*Sub]bool
+	watched map[string]bool
+}
+
+// NewHub creates a new hub keeping up to size events.
+func NewHub(size int) *Hub {
+	return &Hub{
+		size:    size,
+		subs:    make(map[*Sub]bool),
+		watched: make(map[string]bool),
+	}
+}
+
+// Publish adds a new event and sends it to the subscribers. Slow subscribers
+// miss the event, they can use its id to get it again from the hub.
+func (h *Hub) Publish(robot, device, name string, data interface{}) *Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	ev := &Event{
+		ID:     h.seq,
+		Time:   time.Now(),
+		Robot:  robot,
+		Device: device,
+		Name:   name,
+		Data:   data,
+	}
+	h.last = append(h.last, ev)
+	if len(h.last) > h.size {
+		h.last = h.last[len(h.last)-h.size:]
+	}
+	for s := range h.subs {
+		if s.filter.Match(ev) {
+			select {
+			case s.c <- ev:
+			default:
+			}
+		}
+	}
+	return ev
+}
+
+// Subscribe returns a new subscription for the events selected by the filter.
+// If since is not zero, kept events with a greater id are returned too, so
+// clients can resume from the last event they got.
+func (h *Hub) Subscribe(f *Filter, since uint64) ([]*Event, *Sub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	c := make(chan *Event, 100)
+	s := &Sub{C: c, c: c, filter: f}
+	h.subs[s] = true
+	var l []*Event
+	if since > 0 {
+		l = make([]*Event, 0)
+		for _, ev := range h.last {
+			if ev.ID > since && f.Match(ev) {
+				l = append(l, ev)
+			}
+		}
+	}
+	return l, s
+}
+
+// Unsubscribe removes the subscription.
+func (h *Hub) Unsubscribe(s *Sub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, s)
+}
+
+// Watch subscribes to the events of every robot device, publishing them to
+// the hub. Devices already watched are skipped, so it can be called again if
+// robots or devices were added.
+func (h *Hub) Watch(m *gobot.Master) {
+	m.Robots().Each(func(r *gobot.Robot) {
+		r.Devices().Each(func(d gobot.Device) {
+			e, ok := d.(gobot.Eventer)
+			if !ok {
+				return
+			}
+			k := r.Name + "/" + d.Name()
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.watched[k] {
+				return
+			}
+			h.watched[k] = true
+			log.Debugf("watch %s/%s events", r.Name, d.Name())
+			go func(robot, device string, c <-chan *gobot.Event) {
+				for ev := range c {
+					h.Publish(robot, device, ev.Name, ev.Data)
+				}
+			}(r.Name, d.Name(), e.Subscribe())
+		})
+	})
+}
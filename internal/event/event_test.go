@@ -0,0 +1,82 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package event
+
+import (
+	"testing"
+	"time"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/platform/adaptor"
+	"github.com/munbot/master/platform/driver"
+	"github.com/munbot/master/testing/require"
+)
+
+func TestFilter(t *testing.T) {
+	check := require.New(t)
+	ev := &Event{Robot: "r0", Device: "d0", Name: "e0"}
+	var f *Filter
+	check.True(f.Match(ev))
+	check.True((&Filter{}).Match(ev))
+	check.True((&Filter{Robot: "r0", Event: "e0"}).Match(ev))
+	check.False((&Filter{Robot: "r0", Device: "d1"}).Match(ev))
+}
+
+func TestHub(t *testing.T) {
+	check := require.New(t)
+	h := NewHub(3)
+	for i := 0; i < 5; i++ {
+		h.Publish("r0", "d0", "e0", i)
+	}
+	check.Len(h.last, 3)
+
+	l, sub := h.Subscribe(nil, 0)
+	check.Len(l, 0)
+	ev := h.Publish("r0", "d0", "e1", nil)
+	check.Equal(uint64(6), ev.ID)
+	check.Equal(ev, <-sub.C)
+	h.Unsubscribe(sub)
+	check.Len(h.subs, 0)
+
+	l, sub = h.Subscribe(&Filter{Event: "e0"}, 3)
+	defer h.Unsubscribe(sub)
+	check.Len(l, 2)
+	check.Equal(uint64(4), l[0].ID)
+	check.Equal(3, l[0].Data)
+	h.Publish("r0", "d0", "e1", nil)
+	h.Publish("r0", "d0", "e0", nil)
+	check.Equal(uint64(8), (<-sub.C).ID)
+}
+
+func TestEventJSON(t *testing.T) {
+	check := require.New(t)
+	ev := &Event{ID: 1, Robot: "r0", Device: "d0", Name: "e0", Data: make(chan int)}
+	check.Contains(string(ev.JSON()), `"data":"0x`)
+	ev.Data = map[string]int{"v": 1}
+	check.Contains(string(ev.JSON()), `"data":{"v":1}`)
+}
+
+func TestWatch(t *testing.T) {
+	check := require.New(t)
+	m := gobot.NewMaster()
+	d := driver.New(adaptor.New())
+	m.AddRobot(gobot.NewRobot("r0", []gobot.Device{d}))
+	h := NewHub(10)
+	h.Watch(m)
+	h.Watch(m)
+	check.Len(h.watched, 1)
+	_, sub := h.Subscribe(&Filter{Robot: "r0"}, 0)
+	defer h.Unsubscribe(sub)
+	d.Publish("testing", 1)
+	select {
+	case ev := <-sub.C:
+		check.Equal("r0", ev.Robot)
+		check.Equal(d.Name(), ev.Device)
+		check.Equal("testing", ev.Name)
+		check.Equal(1, ev.Data)
+	case <-time.After(time.Second):
+		t.Fatal("event timeout")
+	}
+}
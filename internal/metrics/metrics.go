@@ -0,0 +1,144 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package metrics implements a minimal metrics registry, exported using the
+// prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/munbot/master/log"
+)
+
+// Sample is a metric value with its labels.
+type Sample struct {
+	Suffix string
+	Labels []string
+	Value  float64
+}
+
+// Metric is implemented by every metric type.
+type Metric interface {
+	Name() string
+	Help() string
+	Type() string
+	Samples() []Sample
+}
+
+// Registry holds the registered metrics.
+type Registry struct {
+	mu sync.Mutex
+	db map[string]Metric
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{db: make(map[string]Metric)}
+}
+
+// Default is the registry used by the package level functions.
+var Default *Registry = NewRegistry()
+
+// Register adds the metric to the registry, replacing any previous metric with
+// the same name.
+func (r *Registry) Register(m Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.db[m.Name()] = m
+}
+
+// Get returns the named metric.
+func (r *Registry) Get(name string) (Metric, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, found := r.db[name]
+	return m, found
+}
+
+// Write writes the registered metrics to w, using prometheus text format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	l := make([]Metric, 0, len(r.db))
+	for _, m := range r.db {
+		l = append(l, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(l, func(i, j int) bool { return l[i].Name() < l[j].Name() })
+	for _, m := range l {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.Name(),
+			escapeHelp(m.Help()), m.Name(), m.Type()); err != nil {
+			return err
+		}
+		for _, s := range m.Samples() {
+			if _, err := fmt.Fprintf(w, "%s%s%s %s\n", m.Name(), s.Suffix,
+				formatLabels(s.Labels), formatValue(s.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Register adds the metric to the default registry.
+func Register(m Metric) {
+	Default.Register(m)
+}
+
+// Write writes the default registry metrics.
+func Write(w io.Writer) error {
+	return Default.Write(w)
+}
+
+// Handler returns an http handler serving the default registry metrics.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := Write(w); err != nil {
+			log.Errorf("metrics write: %v", err)
+		}
+	})
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// formatLabels formats the labels list, given as name, value pairs.
+func formatLabels(l []string) string {
+	if len(l) == 0 {
+		return ""
+	}
+	b := new(strings.Builder)
+	b.WriteString("{")
+	for i := 0; i+1 < len(l); i += 2 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(b, `%s="%s"`, l[i], labelEscaper.Replace(l[i+1]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
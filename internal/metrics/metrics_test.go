@@ -0,0 +1,65 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestRegistry(t *testing.T) {
+	check := require.New(t)
+	r := NewRegistry()
+	c := NewCounter("test_total", "Test counter.", "method", "code")
+	c.Inc("GET", "200")
+	c.Add(2, "GET", "200")
+	c.Inc("POST", "4\"0\"4")
+	check.Equal(float64(3), c.Value("GET", "200"))
+	r.Register(c)
+	h := NewHistogram("test_seconds", "Test\nhistogram.", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	r.Register(h)
+	r.Register(NewGaugeFunc("test_gauge", "Test gauge.", func() map[string]float64 {
+		return map[string]float64{"b": 2, "a": 1}
+	}, "name"))
+	r.Register(NewGaugeValue("test_value", "Test value.", func() float64 { return 0.5 }))
+	_, found := r.Get("test_value")
+	check.True(found)
+
+	buf := new(bytes.Buffer)
+	check.NoError(r.Write(buf))
+	check.Equal(`# HELP test_gauge Test gauge.
+# TYPE test_gauge gauge
+test_gauge{name="a"} 1
+test_gauge{name="b"} 2
+# HELP test_seconds Test\nhistogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 5.55
+test_seconds_count 3
+# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{method="GET",code="200"} 3
+test_total{method="POST",code="4\"0\"4"} 1
+# HELP test_value Test value.
+# TYPE test_value gauge
+test_value 0.5
+`, buf.String())
+}
+
+func TestHandler(t *testing.T) {
+	check := require.New(t)
+	Register(NewGaugeValue("test_handler", "Test handler.", func() float64 { return 1 }))
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	check.Equal("text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	check.Contains(w.Body.String(), "\ntest_handler 1\n")
+}
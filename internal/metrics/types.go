@@ -0,0 +1,204 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) Name() string {
+	return d.name
+}
+
+func (d *desc) Help() string {
+	return d.help
+}
+
+// pairs returns the labels names and values as name, value pairs.
+func (d *desc) pairs(values []string) []string {
+	l := make([]string, 0, len(d.labels)*2)
+	for i, n := range d.labels {
+		v := ""
+		if i < len(values) {
+			v = values[i]
+		}
+		l = append(l, n, v)
+	}
+	return l
+}
+
+// Counter is a metric that only goes up, optionally partitioned by labels.
+type Counter struct {
+	desc
+	mu sync.Mutex
+	db map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	v      float64
+}
+
+// NewCounter creates a new counter with the given labels names.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{
+		desc: desc{name: name, help: help, labels: labels},
+		db:   make(map[string]*counterValue),
+	}
+}
+
+func (c *Counter) Type() string {
+	return "counter"
+}
+
+// Add adds v to the counter for the given labels values.
+func (c *Counter) Add(v float64, values ...string) {
+	k := strings.Join(values, "\x00")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cv, found := c.db[k]
+	if !found {
+		cv = &counterValue{labels: c.pairs(values)}
+		c.db[k] = cv
+	}
+	cv.v += v
+}
+
+// Inc increments the counter for the given labels values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Value returns the counter value for the given labels values.
+func (c *Counter) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cv, found := c.db[strings.Join(values, "\x00")]; found {
+		return cv.v
+	}
+	return 0
+}
+
+func (c *Counter) Samples() []Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.db))
+	for k := range c.db {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	l := make([]Sample, 0, len(keys))
+	for _, k := range keys {
+		cv := c.db[k]
+		l = append(l, Sample{Labels: cv.labels, Value: cv.v})
+	}
+	return l
+}
+
+// DefaultBuckets are the histogram buckets used if none are given, in
+// seconds.
+var DefaultBuckets []float64 = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram counts observed values in buckets.
+type Histogram struct {
+	desc
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram creates a new histogram. Buckets upper bounds must be sorted.
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	return &Histogram{
+		desc:    desc{name: name, help: help},
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) Type() string {
+	return "histogram"
+}
+
+// Observe adds a value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *Histogram) Samples() []Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l := make([]Sample, 0, len(h.buckets)+3)
+	for i, b := range h.buckets {
+		l = append(l, Sample{Suffix: "_bucket", Labels: []string{"le", formatValue(b)},
+			Value: float64(h.counts[i])})
+	}
+	l = append(l, Sample{Suffix: "_bucket", Labels: []string{"le", "+Inf"}, Value: float64(h.count)})
+	l = append(l, Sample{Suffix: "_sum", Value: h.sum})
+	l = append(l, Sample{Suffix: "_count", Value: float64(h.count)})
+	return l
+}
+
+// GaugeFunc is a gauge which values are read when collected. The function
+// returns a value for each labels values set, keyed by the values joined with
+// commas, or a single value keyed by an empty string if there are no labels.
+type GaugeFunc struct {
+	desc
+	fn func() map[string]float64
+}
+
+// NewGaugeFunc creates a new gauge with the given labels names.
+func NewGaugeFunc(name, help string, fn func() map[string]float64, labels ...string) *GaugeFunc {
+	return &GaugeFunc{desc: desc{name: name, help: help, labels: labels}, fn: fn}
+}
+
+// NewGaugeValue creates a new gauge without labels.
+func NewGaugeValue(name, help string, fn func() float64) *GaugeFunc {
+	return NewGaugeFunc(name, help, func() map[string]float64 {
+		return map[string]float64{"": fn()}
+	})
+}
+
+func (g *GaugeFunc) Type() string {
+	return "gauge"
+}
+
+func (g *GaugeFunc) Samples() []Sample {
+	m := g.fn()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	l := make([]Sample, 0, len(keys))
+	for _, k := range keys {
+		var values []string
+		if len(g.labels) > 0 {
+			values = strings.Split(k, ",")
+		}
+		l = append(l, Sample{Labels: g.pairs(values), Value: m[k]})
+	}
+	return l
+}
@@ -0,0 +1,77 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/munbot/master/log"
+)
+
+// listenFdsStart is the first passed file descriptor.
+var listenFdsStart int = 3
+
+// Files returns the sockets passed by the service manager, indexed by their
+// FileDescriptorName. Unnamed sockets are closed. Activation env settings are
+// removed, so child processes do not inherit them. On error all the passed
+// sockets are closed.
+//
+// The files are kept open, so a listener can be created from them again if a
+// server is restarted.
+func Files() (map[string]*os.File, error) {
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+	defer os.Unsetenv("LISTEN_FDNAMES")
+	files := make(map[string]*os.File)
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return files, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return files, fmt.Errorf("systemd: invalid LISTEN_FDS: %q", os.Getenv("LISTEN_FDS"))
+	}
+	var names []string
+	if fdnames, ok := os.LookupEnv("LISTEN_FDNAMES"); ok {
+		names = strings.Split(fdnames, ":")
+	} else if n > 0 {
+		log.Warnf("Systemd LISTEN_FDNAMES not set, closing %d unnamed socket(s)", n)
+	}
+	for i := 0; i < n; i++ {
+		fd := listenFdsStart + i
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		closeOnExec(fd)
+		f := os.NewFile(uintptr(fd), name)
+		if name == "" || name == "unknown" {
+			f.Close()
+			continue
+		}
+		if _, found := files[name]; found {
+			f.Close()
+			closeFiles(files)
+			closeFds(listenFdsStart+i+1, listenFdsStart+n)
+			return nil, fmt.Errorf("systemd: socket %s passed twice", name)
+		}
+		files[name] = f
+	}
+	return files, nil
+}
+
+func closeFiles(files map[string]*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+// closeFds closes the file descriptors from start up to end, not included.
+func closeFds(start, end int) {
+	for fd := start; fd < end; fd++ {
+		os.NewFile(uintptr(fd), "").Close()
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package systemd
+
+func closeOnExec(fd int) {}
@@ -0,0 +1,109 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestFiles(t *testing.T) {
+	check := require.New(t)
+	defer func(n int) { listenFdsStart = n }(listenFdsStart)
+
+	os.Unsetenv("LISTEN_PID")
+	files, err := Files()
+	check.NoError(err)
+	check.Len(files, 0)
+
+	// not for us
+	os.Setenv("LISTEN_PID", "1")
+	os.Setenv("LISTEN_FDS", "1")
+	files, err = Files()
+	check.NoError(err)
+	check.Len(files, 0)
+	check.Equal("", os.Getenv("LISTEN_FDS"))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	check.NoError(err)
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	check.NoError(err)
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	check.NoError(err)
+	listenFdsStart = fd
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "1")
+	os.Setenv("LISTEN_FDNAMES", "api")
+	files, err = Files()
+	check.NoError(err)
+	check.Len(files, 1)
+	check.Equal("", os.Getenv("LISTEN_PID"))
+	check.Equal("", os.Getenv("LISTEN_FDNAMES"))
+	sf := files["api"]
+	check.NotNil(sf)
+	defer sf.Close()
+
+	// a listener can be created more than once
+	for i := 0; i < 2; i++ {
+		l, err := net.FileListener(sf)
+		check.NoError(err)
+		check.Equal(ln.Addr().String(), l.Addr().String())
+		check.NoError(l.Close())
+	}
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "invalid")
+	_, err = Files()
+	check.EqualError(err, `systemd: invalid LISTEN_FDS: "invalid"`)
+
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "3")
+	os.Setenv("LISTEN_FDNAMES", "api:api:console")
+	listenFdsStart = dupFds(t, ln, 3)
+	_, err = Files()
+	check.EqualError(err, "systemd: socket api passed twice")
+	for i := 0; i < 3; i++ {
+		var st syscall.Stat_t
+		check.Equal(syscall.EBADF, syscall.Fstat(listenFdsStart+i, &st), "fd %d closed", i)
+	}
+
+	// no names
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	os.Setenv("LISTEN_FDS", "1")
+	listenFdsStart = dupFds(t, ln, 1)
+	files, err = Files()
+	check.NoError(err)
+	check.Len(files, 0)
+	var st syscall.Stat_t
+	check.Equal(syscall.EBADF, syscall.Fstat(listenFdsStart, &st), "unnamed fd closed")
+}
+
+// dupFds duplicates the listener file descriptor n times, as consecutive file
+// descriptors, and returns the first one.
+func dupFds(t *testing.T, ln net.Listener, n int) int {
+	check := require.New(t)
+	f, err := ln.(*net.TCPListener).File()
+	check.NoError(err)
+	defer f.Close()
+	start := 200
+	for i := 0; i < n; i++ {
+		fd, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_DUPFD, uintptr(start+i))
+		if errno != 0 {
+			t.Fatal(errno)
+		}
+		check.Equal(start+i, int(fd))
+	}
+	return start
+}
@@ -0,0 +1,15 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package systemd
+
+import (
+	"syscall"
+)
+
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
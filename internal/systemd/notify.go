@@ -0,0 +1,72 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package systemd implements the systemd service notification and socket
+// activation protocols, without using libsystemd.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notify states.
+const (
+	Ready     string = "READY=1"
+	Reloading string = "RELOADING=1"
+	Stopping  string = "STOPPING=1"
+	Watchdog  string = "WATCHDOG=1"
+)
+
+// Status returns the STATUS notify state for msg.
+func Status(msg string) string {
+	return "STATUS=" + strings.Replace(msg, "\n", " ", -1)
+}
+
+// Enabled returns true if the service manager expects notifications.
+func Enabled() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
+
+// Notify sends the states to the service manager socket, one per line. It
+// returns false if NOTIFY_SOCKET is not set.
+func Notify(states ...string) (bool, error) {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return false, nil
+	}
+	if strings.HasPrefix(name, "@") {
+		// abstract socket
+		name = "\x00" + name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the time the service manager waits for a watchdog
+// ping, or 0 if it's not enabled for this process.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("systemd: invalid WATCHDOG_USEC: %q", usec)
+	}
+	return time.Duration(n) * time.Microsecond, nil
+}
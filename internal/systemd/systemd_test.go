@@ -0,0 +1,81 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package systemd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestNotify(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_systemd_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "notify.socket")
+
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	os.Unsetenv("NOTIFY_SOCKET")
+	check.False(Enabled())
+	sent, err := Notify(Ready)
+	check.NoError(err)
+	check.False(sent)
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: fn, Net: "unixgram"})
+	check.NoError(err)
+	defer conn.Close()
+	os.Setenv("NOTIFY_SOCKET", fn)
+	check.True(Enabled())
+	sent, err = Notify(Ready, Status("testing\nstatus"))
+	check.NoError(err)
+	check.True(sent)
+
+	buf := make([]byte, 1024)
+	check.NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	check.NoError(err)
+	check.Equal("READY=1\nSTATUS=testing status", string(buf[:n]))
+
+	os.Setenv("NOTIFY_SOCKET", filepath.Join(dir, "nonexistent.socket"))
+	_, err = Notify(Stopping)
+	check.Error(err)
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	check := require.New(t)
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	os.Unsetenv("WATCHDOG_USEC")
+	d, err := WatchdogInterval()
+	check.NoError(err)
+	check.Equal(time.Duration(0), d)
+
+	os.Setenv("WATCHDOG_USEC", "3000000")
+	d, err = WatchdogInterval()
+	check.NoError(err)
+	check.Equal(3*time.Second, d)
+
+	os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	d, err = WatchdogInterval()
+	check.NoError(err)
+	check.Equal(3*time.Second, d)
+
+	os.Setenv("WATCHDOG_PID", "1")
+	d, err = WatchdogInterval()
+	check.NoError(err)
+	check.Equal(time.Duration(0), d)
+
+	os.Unsetenv("WATCHDOG_PID")
+	os.Setenv("WATCHDOG_USEC", "invalid")
+	_, err = WatchdogInterval()
+	check.EqualError(err, `systemd: invalid WATCHDOG_USEC: "invalid"`)
+}
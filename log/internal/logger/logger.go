@@ -82,6 +82,10 @@ func (l *Logger) SetOutput(out io.Writer) {
 	l.out = out
 }
 
+func (l *Logger) Writer() io.Writer {
+	return l.log.Writer()
+}
+
 func (l *Logger) SetFlags(f int) {
 	l.Lock()
 	defer l.Unlock()
@@ -126,6 +126,10 @@ func SetOutput(out io.Writer) {
 	l.SetOutput(out)
 }
 
+func Writer() io.Writer {
+	return l.Writer()
+}
+
 func Output(calldepth int, s string) error {
 	return l.Output(calldepth, s)
 }
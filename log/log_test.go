@@ -87,6 +87,10 @@ func (s *Suite) TestSetPrefix() {
 	s.Equal(prefix, l.Prefix(), "logger set prefix")
 }
 
+func (s *Suite) TestWriter() {
+	s.Equal(s.buf, Writer(), "logger writer")
+}
+
 func (s *Suite) TestPrint() {
 	Print("test")
 	s.Regexp("^\\d\\d\\d\\d/\\d\\d/\\d\\d \\d\\d:\\d\\d:\\d\\d.\\d\\d\\d\\d\\d\\d test\n$", s.buf.String(), "print msg")
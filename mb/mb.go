@@ -14,14 +14,25 @@ import (
 	"github.com/munbot/master/env"
 	"github.com/munbot/master/internal/core"
 	"github.com/munbot/master/log"
+	"github.com/munbot/master/service"
 )
 
+// RegisterFunc adds services to the runtime. It's called after the core
+// builtin services were registered, before any of them is configured.
+type RegisterFunc func(r service.Registrar) error
+
 type Cmd struct {
 	flags *core.Flags
+	reg   []RegisterFunc
 }
 
 func New() *Cmd {
-	return &Cmd{flags: core.NewFlags()}
+	return &Cmd{flags: core.NewFlags(), reg: make([]RegisterFunc, 0)}
+}
+
+// OnRegister adds fn to be called to register custom runtime services.
+func (c *Cmd) OnRegister(fn RegisterFunc) {
+	c.reg = append(c.reg, fn)
 }
 
 func (c *Cmd) FlagSet(fs *flag.FlagSet) {
@@ -29,7 +40,9 @@ func (c *Cmd) FlagSet(fs *flag.FlagSet) {
 }
 
 func (c *Cmd) Command(cf *config.Flags) cmd.Command {
-	return newMain(c.flags, cf)
+	m := newMain(c.flags, cf)
+	m.reg = c.reg
+	return m
 }
 
 type Main struct {
@@ -37,6 +50,7 @@ type Main struct {
 	cf  *config.Flags
 	rt  core.Runtime
 	cfg *config.Config
+	reg []RegisterFunc
 }
 
 func newMain(kf *core.Flags, cf *config.Flags) *Main {
@@ -65,6 +79,12 @@ func (m *Main) Run(args []string) int {
 	if _, err := m.rt.Init(ctx, m.cf, m.cfg); err != nil {
 		return 10
 	}
+	for _, fn := range m.reg {
+		if err := fn(m.rt); err != nil {
+			log.Errorf("register services: %v", err)
+			return 10
+		}
+	}
 	if err := m.rt.Configure(); err != nil {
 		return 11
 	}
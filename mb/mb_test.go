@@ -2,3 +2,79 @@
 // See LICENSE file.
 
 package mb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/munbot/master/config"
+	"github.com/munbot/master/internal/core"
+	"github.com/munbot/master/service"
+	"github.com/munbot/master/testing/require"
+)
+
+type testRuntime struct {
+	core.Runtime
+	calls    []string
+	policies map[string]service.Policy
+}
+
+func (rt *testRuntime) Init(ctx context.Context, cfl *config.Flags, cfg *config.Config) (context.Context, error) {
+	rt.calls = append(rt.calls, "init")
+	return ctx, nil
+}
+
+func (rt *testRuntime) Configure() error {
+	rt.calls = append(rt.calls, "configure")
+	return nil
+}
+
+func (rt *testRuntime) Start() error {
+	rt.calls = append(rt.calls, "start")
+	return nil
+}
+
+func (rt *testRuntime) Register(s service.Service, deps ...string) error {
+	rt.calls = append(rt.calls, "register "+s.Name())
+	return nil
+}
+
+func (rt *testRuntime) SetPolicy(name string, p service.Policy) error {
+	rt.policies[name] = p
+	return nil
+}
+
+type testService struct {
+	service.Service
+}
+
+func (s *testService) Name() string {
+	return "testing"
+}
+
+func TestOnRegister(t *testing.T) {
+	check := require.New(t)
+	c := New()
+	c.OnRegister(func(r service.Registrar) error {
+		if err := r.Register(&testService{}, "robot"); err != nil {
+			return err
+		}
+		return r.SetPolicy("testing", service.Policy{Restart: service.RestartAlways})
+	})
+	m := c.Command(&config.Flags{}).(*Main)
+	rt := &testRuntime{policies: make(map[string]service.Policy)}
+	m.rt = rt
+	check.Equal(0, m.Run([]string{}))
+	check.Equal([]string{"init", "register testing", "configure", "start"}, rt.calls)
+	check.Equal(service.RestartAlways, rt.policies["testing"].Restart)
+
+	c.OnRegister(func(r service.Registrar) error {
+		return errors.New("testing")
+	})
+	m = c.Command(&config.Flags{}).(*Main)
+	rt = &testRuntime{policies: make(map[string]service.Policy)}
+	m.rt = rt
+	check.Equal(10, m.Run([]string{}))
+	check.Equal([]string{"init", "register testing"}, rt.calls)
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/internal/api/wapp"
 )
 
 type Error struct {
@@ -16,15 +18,18 @@ type Error struct {
 func (m *Robot) addCommands(mbot *gobot.Master) {
 	mbot.AddCommand("status", m.status)
 	mbot.AddCommand("exit", m.exit)
+	mbot.AddCommand("reload", m.reload)
 }
 
 type Status struct {
-	Born   string `json:"born"`
-	Uptime string `json:"uptime"`
-	State  string `json:"state"`
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
-	Die    string `json:"die,omitempty"`
+	Born     string                 `json:"born"`
+	Uptime   string                 `json:"uptime"`
+	State    string                 `json:"state"`
+	Status   string                 `json:"status"`
+	Error    string                 `json:"error,omitempty"`
+	Die      string                 `json:"die,omitempty"`
+	Services []*wapp.ServiceInfo    `json:"services,omitempty"`
+	History  []*wapp.TransitionInfo `json:"history,omitempty"`
 }
 
 func (m *Robot) newStatus() *Status {
@@ -34,7 +39,7 @@ func (m *Robot) newStatus() *Status {
 		status = "error"
 		err = m.err.Error()
 	}
-	return &Status{
+	s := &Status{
 		Born:   m.born.String(),
 		Uptime: m.Uptime().String(),
 		State:  m.state,
@@ -42,6 +47,11 @@ func (m *Robot) newStatus() *Status {
 		Error:  err,
 		Die:    "",
 	}
+	if m.rt != nil {
+		s.Services = m.rt.Services()
+		s.History = m.rt.Transitions()
+	}
+	return s
 }
 
 func (m *Robot) status(args map[string]interface{}) interface{} {
@@ -55,6 +65,15 @@ func (m *Robot) exit(args map[string]interface{}) interface{} {
 	s := m.newStatus()
 	s.Die = time.Now().String()
 	s.Status = "exit"
-	m.exitc <- true
+	m.Exit()
+	return s
+}
+
+func (m *Robot) reload(args map[string]interface{}) interface{} {
+	if err := m.Reload(); err != nil {
+		return Error{err.Error()}
+	}
+	s := m.newStatus()
+	s.Status = "reload"
 	return s
 }
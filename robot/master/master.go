@@ -5,6 +5,7 @@
 package master
 
 import (
+	"errors"
 	"net/http"
 	"sync"
 	"time"
@@ -14,10 +15,13 @@ import (
 
 	"github.com/munbot/master/env"
 	"github.com/munbot/master/internal/api/wapp"
+	"github.com/munbot/master/internal/event"
 	"github.com/munbot/master/log"
 	"github.com/munbot/master/platform"
 )
 
+var eventsSize int = 1000
+
 type Config struct {
 	Name string
 }
@@ -28,10 +32,13 @@ type Robot struct {
 	*gobot.Master
 	name  string
 	api   wapp.Api
+	rt    wapp.Runtime
+	hub   *event.Hub
 	state string
 	born  time.Time
 	err   error
 	exitc chan<- bool
+	relc  chan<- bool
 	stop  chan bool
 	rw    *sync.RWMutex
 }
@@ -47,6 +54,7 @@ func NewRobot() *Robot {
 		Master: m,
 		name:   env.Get("MUNBOT"),
 		api:    wapp.New(api.NewAPI(m)),
+		hub:    event.NewHub(eventsSize),
 		state:  "Init",
 		born:   time.Now(),
 		stop:   make(chan bool, 0),
@@ -63,6 +71,7 @@ func NewRobot() *Robot {
 func (m *Robot) Start() error {
 	log.Debugf("start master robot %s...", m.name)
 	autorun := false
+	m.hub.Watch(m.Master)
 	return m.Master.Robots().Start(autorun)
 }
 
@@ -85,16 +94,57 @@ func (m *Robot) ExitNotify(c chan<- bool) {
 	m.exitc = c
 }
 
+func (m *Robot) ReloadNotify(c chan<- bool) {
+	m.relc = c
+}
+
+// Reload notifies a config reload request. It returns an error if nobody is
+// listening.
+func (m *Robot) Reload() error {
+	if m.relc == nil {
+		return errors.New("master: reload not available")
+	}
+	select {
+	case m.relc <- true:
+	default:
+		log.Debug("reload already notified")
+	}
+	return nil
+}
+
+// Exit notifies the exit request. It returns an error if nobody is listening.
+func (m *Robot) Exit() error {
+	if m.exitc == nil {
+		return errors.New("master: nothing to do here")
+	}
+	select {
+	case m.exitc <- true:
+	default:
+		log.Debug("exit already notified")
+	}
+	return nil
+}
+
 func (m *Robot) Configure(c *Config, wc *wapp.Config) error {
 	m.rw.Lock()
 	defer m.rw.Unlock()
 	if c.Name != "" {
 		m.name = c.Name
 	}
+	m.rt = wc.Runtime
 	m.api.Configure(wc)
 	return nil
 }
 
+func (m *Robot) Events() *event.Hub {
+	return m.hub
+}
+
+func (m *Robot) Gobot() *gobot.Master {
+	return m.Master
+}
+
 func (m *Robot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.countCommand(r.URL.Path)
 	m.api.ServeHTTP(w, r)
 }
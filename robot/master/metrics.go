@@ -0,0 +1,73 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package master
+
+import (
+	"strings"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/internal/metrics"
+)
+
+var commandsTotal = metrics.NewCounter("munbot_commands_total",
+	"Robots and devices commands run, by robot, device and command.",
+	"robot", "device", "command")
+
+func init() {
+	metrics.Register(commandsTotal)
+}
+
+// CommandRun counts a robot or device command run. Robots commands use an
+// empty device name.
+func CommandRun(robot, device, command string) {
+	commandsTotal.Inc(robot, device, command)
+}
+
+// commandPath parses the robot, device and command names from an api path like
+// robots/{robot}/commands/{command} or
+// robots/{robot}/devices/{device}/commands/{command}.
+func commandPath(p string) (robot, device, command string, ok bool) {
+	l := strings.Split(strings.Trim(p, "/"), "/")
+	for i, n := range l {
+		if n != "robots" {
+			continue
+		}
+		x := l[i+1:]
+		if len(x) == 3 && x[1] == "commands" {
+			return x[0], "", x[2], true
+		}
+		if len(x) == 5 && x[1] == "devices" && x[3] == "commands" {
+			return x[0], x[2], x[4], true
+		}
+		return "", "", "", false
+	}
+	return "", "", "", false
+}
+
+// countCommand counts the command from the api request path, if it's a known
+// robot or device command, so unknown names do not grow the metric labels.
+func (m *Robot) countCommand(p string) {
+	robot, device, cmd, ok := commandPath(p)
+	if !ok {
+		return
+	}
+	r := m.Master.Robot(robot)
+	if r == nil {
+		return
+	}
+	var c gobot.Commander = r
+	if device != "" {
+		d := r.Device(device)
+		if d == nil {
+			return
+		}
+		if c, ok = d.(gobot.Commander); !ok {
+			return
+		}
+	}
+	if c.Command(cmd) != nil {
+		CommandRun(robot, device, cmd)
+	}
+}
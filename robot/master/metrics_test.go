@@ -0,0 +1,39 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package master
+
+import (
+	"testing"
+
+	"gobot.io/x/gobot"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestCommandPath(t *testing.T) {
+	check := require.New(t)
+	r, d, c, ok := commandPath("/api/robots/r0/devices/d0/commands/c0")
+	check.True(ok)
+	check.Equal([]string{"r0", "d0", "c0"}, []string{r, d, c})
+	r, d, c, ok = commandPath("/api/robots/r0/commands/c0")
+	check.True(ok)
+	check.Equal([]string{"r0", "", "c0"}, []string{r, d, c})
+	_, _, _, ok = commandPath("/api/commands/status")
+	check.False(ok)
+	_, _, _, ok = commandPath("/api/robots/r0/devices")
+	check.False(ok)
+}
+
+func TestCountCommand(t *testing.T) {
+	check := require.New(t)
+	m := NewRobot()
+	d := m.Gobot().Robot("Munbot").Device("munbot").(gobot.Commander)
+	d.AddCommand("count", func(map[string]interface{}) interface{} { return nil })
+	n := commandsTotal.Value("Munbot", "munbot", "count")
+	m.countCommand("/robots/Munbot/devices/munbot/commands/count")
+	m.countCommand("/robots/Munbot/devices/munbot/commands/unknown")
+	m.countCommand("/robots/Nobot/devices/munbot/commands/count")
+	check.Equal(n+1, commandsTotal.Value("Munbot", "munbot", "count"))
+	check.Equal(float64(0), commandsTotal.Value("Munbot", "munbot", "unknown"))
+}
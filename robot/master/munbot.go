@@ -7,7 +7,10 @@ import (
 	"net/http"
 	"time"
 
+	"gobot.io/x/gobot"
+
 	"github.com/munbot/master/internal/api/wapp"
+	"github.com/munbot/master/internal/event"
 )
 
 type Munbot interface {
@@ -18,5 +21,10 @@ type Munbot interface {
 	ServeHTTP(http.ResponseWriter, *http.Request)
 	CurrentState(string)
 	ExitNotify(chan<- bool)
+	Exit() error
+	ReloadNotify(chan<- bool)
+	Reload() error
 	Uptime() time.Duration
+	Gobot() *gobot.Master
+	Events() *event.Hub
 }
@@ -0,0 +1,51 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package service exposes the runtime services api, so commands built on top
+// of munbot can run their own services along with the core ones.
+package service
+
+import (
+	"github.com/munbot/master/internal/core"
+)
+
+// Service is a runtime service managed by the core.
+//
+// Configure is called at Init state, in dependencies order. Start may block
+// until Stop is called, the core runs it in background and waits for Ready
+// before starting the services which depend on it. Stop is called in reverse
+// order, even if the service was not started.
+type Service = core.Service
+
+// Policy is a service restart policy. Restarts are delayed by Backoff, which
+// is doubled on each retry up to MaxBackoff. Retries are counted since the
+// last time the service was running for MaxBackoff at least. A MaxRetries
+// value of 0 means no limit.
+type Policy = core.Policy
+
+// RestartMode sets when a service is restarted.
+type RestartMode = core.RestartMode
+
+const (
+	// RestartNever does not restart the service.
+	RestartNever RestartMode = core.RestartNever
+	// RestartOnFailure restarts the service if it returns an error.
+	RestartOnFailure RestartMode = core.RestartOnFailure
+	// RestartAlways restarts the service whenever it exits.
+	RestartAlways RestartMode = core.RestartAlways
+)
+
+// DefaultPolicy is used for services without a policy set. They are not
+// restarted and their failure aborts the core.
+var DefaultPolicy Policy = core.DefaultPolicy
+
+// Registrar adds services to the runtime. Core builtin services (auth, robot,
+// api and console) can be used as dependencies.
+type Registrar interface {
+	// Register adds a service, which will be started after the deps services.
+	Register(s Service, deps ...string) error
+	// SetPolicy sets the named service restart policy.
+	SetPolicy(name string, p Policy) error
+}
+
+var _ Registrar = core.Runtime(nil)
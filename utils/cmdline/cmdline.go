@@ -0,0 +1,84 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package cmdline parses the command lines used by the console and mb ctl.
+package cmdline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Split splits the command line in its arguments. Single and double quotes
+// can be used to group words and backslash escapes the next char (except inside
+// single quotes).
+func Split(line string) ([]string, error) {
+	args := make([]string, 0)
+	arg := new(strings.Builder)
+	inArg := false
+	var quote rune
+	escape := false
+	for _, r := range line {
+		if escape {
+			arg.WriteRune(r)
+			escape = false
+			continue
+		}
+		switch {
+		case r == '\\' && quote != '\'':
+			escape = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escape {
+		return nil, errors.New("unfinished escape sequence")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote: %c", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// Params parses key=value arguments as commands params. Values are
+// decoded as JSON if possible, otherwise they are used as plain strings.
+func Params(args []string) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for _, a := range args {
+		i := strings.Index(a, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid param %q, expected key=value", a)
+		}
+		key := a[:i]
+		val := a[i+1:]
+		var v interface{}
+		if err := json.Unmarshal([]byte(val), &v); err != nil {
+			v = val
+		}
+		params[key] = v
+	}
+	return params, nil
+}
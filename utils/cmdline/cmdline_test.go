@@ -0,0 +1,59 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/munbot/master/testing/assert"
+)
+
+func TestSplit(t *testing.T) {
+	check := assert.New(t)
+	for line, expect := range map[string][]string{
+		"":                      {},
+		"  ":                    {},
+		"status":                {"status"},
+		" help  status ":        {"help", "status"},
+		`cmd r d c msg="a b"`:   {"cmd", "r", "d", "c", "msg=a b"},
+		`say 'it\'s'`:           nil,
+		`say "it's"`:            {"say", "it's"},
+		`say a\ b`:              {"say", "a b"},
+		`say ""`:                {"say", ""},
+		`say 'single \"quoted'`: {"say", `single \"quoted`},
+		`say "double \"quoted"`: {"say", `double "quoted`},
+	} {
+		args, err := Split(line)
+		if expect == nil {
+			check.Error(err, line)
+			continue
+		}
+		check.NoError(err, line)
+		check.Equal(expect, args, line)
+	}
+}
+
+func TestSplitError(t *testing.T) {
+	check := assert.New(t)
+	_, err := Split(`say "unclosed`)
+	check.EqualError(err, "unclosed quote: \"")
+	_, err = Split(`say \`)
+	check.EqualError(err, "unfinished escape sequence")
+}
+
+func TestParams(t *testing.T) {
+	check := assert.New(t)
+	p, err := Params([]string{"a=1", "b=true", "c=text", "d="})
+	check.NoError(err)
+	check.Equal(map[string]interface{}{
+		"a": float64(1),
+		"b": true,
+		"c": "text",
+		"d": "",
+	}, p)
+	_, err = Params([]string{"=1"})
+	check.Error(err)
+	_, err = Params([]string{"a"})
+	check.Error(err)
+}
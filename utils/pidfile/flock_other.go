@@ -0,0 +1,34 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package pidfile
+
+import (
+	"os"
+)
+
+// advisory locks are not implemented here, so the pid is checked instead.
+const hasFlock bool = false
+
+func lockFile(fh *os.File) error {
+	return nil
+}
+
+func isLocked(err error) bool {
+	return false
+}
+
+func alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
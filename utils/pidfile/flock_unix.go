@@ -0,0 +1,32 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package pidfile
+
+import (
+	"os"
+	"syscall"
+)
+
+const hasFlock bool = true
+
+// lockFile takes an exclusive advisory lock on the file, without blocking.
+func lockFile(fh *os.File) error {
+	return syscall.Flock(int(fh.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+func isLocked(err error) bool {
+	return err == syscall.EWOULDBLOCK || err == syscall.EAGAIN
+}
+
+// alive checks if the process exists sending it the null signal.
+func alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,194 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+// Package pidfile implements a process id file with an advisory lock, so only
+// one process can hold it.
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/munbot/master/log"
+)
+
+// ErrLocked is returned when the pidfile is held by another process.
+var ErrLocked error = errors.New("pidfile: locked")
+
+// ErrNotRunning is returned when no process holds the pidfile.
+var ErrNotRunning error = errors.New("pidfile: process not running")
+
+// LockedError is returned when the pidfile is held by another process.
+type LockedError struct {
+	Path string
+	PID  int
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("locked by pid %d (%s)", e.PID, e.Path)
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// File is a locked pidfile.
+type File struct {
+	path string
+	fh   *os.File
+}
+
+// Lock creates the pidfile at path, or reuses a stale one, and writes the
+// current process id on it. The lock is held until Unlock is called or the
+// process exits.
+func Lock(path string) (*File, error) {
+	for {
+		fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0640)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockFile(fh); err != nil {
+			fh.Close()
+			if !isLocked(err) {
+				return nil, err
+			}
+			pid, _ := Read(path)
+			return nil, &LockedError{Path: path, PID: pid}
+		}
+		if !hasFlock {
+			if pid, err := parse(fh); err == nil && pid != os.Getpid() && alive(pid) {
+				fh.Close()
+				return nil, &LockedError{Path: path, PID: pid}
+			}
+		}
+		// the file could be removed by its previous owner after we opened it
+		if same, err := sameFile(fh, path); err != nil || !same {
+			fh.Close()
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			continue
+		}
+		f := &File{path: path, fh: fh}
+		if err := f.write(); err != nil {
+			f.Unlock()
+			return nil, err
+		}
+		return f, nil
+	}
+}
+
+func sameFile(fh *os.File, path string) (bool, error) {
+	a, err := fh.Stat()
+	if err != nil {
+		return false, err
+	}
+	b, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(a, b), nil
+}
+
+// write saves the current pid, checking if the previous one was stale.
+func (f *File) write() error {
+	if pid, err := parse(f.fh); err == nil && pid != os.Getpid() {
+		log.Warnf("Stale pidfile %s from pid %d", f.path, pid)
+	}
+	if err := f.fh.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.fh.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+	return f.fh.Sync()
+}
+
+// Path returns the pidfile path.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Unlock removes the pidfile and releases the lock.
+func (f *File) Unlock() error {
+	if f.fh == nil {
+		return nil
+	}
+	err := os.Remove(f.path)
+	if cerr := f.fh.Close(); err == nil {
+		err = cerr
+	}
+	f.fh = nil
+	return err
+}
+
+func parse(fh *os.File) (int, error) {
+	if _, err := fh.Seek(0, 0); err != nil {
+		return 0, err
+	}
+	blob, err := ioutil.ReadAll(fh)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(blob)))
+	if err != nil {
+		return 0, fmt.Errorf("pidfile: invalid pid: %v", err)
+	}
+	return pid, nil
+}
+
+// Read returns the process id saved at path.
+func Read(path string) (int, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer fh.Close()
+	return parse(fh)
+}
+
+// Running returns the process id holding the pidfile at path. If the pidfile
+// does not exist or it is stale, ErrNotRunning is returned.
+func Running(path string) (int, error) {
+	pid, err := Read(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, ErrNotRunning
+		}
+		return 0, err
+	}
+	held, err := probe(path, pid)
+	if err != nil {
+		return 0, err
+	}
+	if !held {
+		return 0, ErrNotRunning
+	}
+	return pid, nil
+}
+
+// probe checks if the pidfile lock is held by pid, without taking it.
+func probe(path string, pid int) (bool, error) {
+	if !hasFlock {
+		return alive(pid), nil
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer fh.Close()
+	if err := lockFile(fh); err != nil {
+		if isLocked(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Jeremías Casteglione <jrmsdev@gmail.com>
+// See LICENSE file.
+
+package pidfile
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/munbot/master/testing/require"
+)
+
+func TestLock(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_pidfile_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "master.pid")
+
+	_, err = Running(fn)
+	check.Equal(ErrNotRunning, err)
+
+	f, err := Lock(fn)
+	check.NoError(err)
+	check.Equal(fn, f.Path())
+	pid, err := Read(fn)
+	check.NoError(err)
+	check.Equal(os.Getpid(), pid)
+
+	_, err = Lock(fn)
+	check.True(errors.Is(err, ErrLocked))
+	check.EqualError(err, "locked by pid "+strconv.Itoa(os.Getpid())+" ("+fn+")")
+	var lerr *LockedError
+	check.True(errors.As(err, &lerr))
+	check.Equal(os.Getpid(), lerr.PID)
+
+	pid, err = Running(fn)
+	check.NoError(err)
+	check.Equal(os.Getpid(), pid)
+
+	check.NoError(f.Unlock())
+	check.NoError(f.Unlock())
+	_, err = os.Stat(fn)
+	check.True(os.IsNotExist(err))
+	_, err = Running(fn)
+	check.Equal(ErrNotRunning, err)
+}
+
+func TestStale(t *testing.T) {
+	check := require.New(t)
+	dir, err := ioutil.TempDir("", "munbot_test_pidfile_")
+	check.NoError(err)
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "master.pid")
+
+	// a pidfile left by a dead process
+	check.NoError(ioutil.WriteFile(fn, []byte("999999999\n"), 0640))
+	_, err = Running(fn)
+	check.Equal(ErrNotRunning, err)
+
+	f, err := Lock(fn)
+	check.NoError(err)
+	defer f.Unlock()
+	pid, err := Read(fn)
+	check.NoError(err)
+	check.Equal(os.Getpid(), pid)
+
+	check.NoError(ioutil.WriteFile(fn+".bad", []byte("nopid"), 0640))
+	_, err = Read(fn + ".bad")
+	check.Error(err)
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 // MockFile implements File interface for testing purposes mainly. It's mainly
@@ -103,6 +104,40 @@ func (fs *MockFilesystem) MkdirAll(path string) error {
 	return nil
 }
 
+// Chmod does nothing but it returns a "file not found" error if the named file
+// is not in the root tree.
+func (fs *MockFilesystem) Chmod(name string, mode os.FileMode) error {
+	if _, found := fs.root[name]; !found {
+		return fs.notfound(name)
+	}
+	return nil
+}
+
+// Rename moves the named file in the root tree. It returns a "file not found"
+// error if oldname is not in the root tree.
+func (fs *MockFilesystem) Rename(oldname, newname string) error {
+	fh, found := fs.root[oldname]
+	if !found {
+		return fs.notfound(oldname)
+	}
+	delete(fs.root, oldname)
+	delete(fs.stat, oldname)
+	delete(fs.stat, newname)
+	fs.root[newname] = fh
+	return nil
+}
+
+// Remove removes the named file from the root tree. It returns a "file not
+// found" error if it is not in the root tree.
+func (fs *MockFilesystem) Remove(name string) error {
+	if _, found := fs.root[name]; !found {
+		return fs.notfound(name)
+	}
+	delete(fs.root, name)
+	delete(fs.stat, name)
+	return nil
+}
+
 // Add adds a new file to the root tree (if it already exists it is silently
 // overriden). It returns the new file handler.
 func (fs *MockFilesystem) Add(filename string) *MockFile {
@@ -140,7 +175,7 @@ func (fs *MockFilesystem) Stat(name string) (os.FileInfo, error) {
 	if ok {
 		return i, nil
 	}
-	fh, err := fs.tempfile("", name)
+	fh, err := fs.tempfile("", filepath.Base(name))
 	if err != nil {
 		return nil, err
 	}
@@ -166,3 +166,24 @@ func (s *MockSuite) TestStatTempFileError() {
 	_, err := s.fs.Stat("testing.txt")
 	s.require.EqualError(err, "mock tempfile error", "fs stat tempfile error")
 }
+
+func (s *MockSuite) TestRename() {
+	s.fs.Add("testing.txt").WriteString("test")
+	s.require.NoError(s.fs.Rename("testing.txt", "renamed.txt"), "fs rename")
+	_, err := s.fs.Stat("testing.txt")
+	s.require.True(os.IsNotExist(err), "renamed file not found")
+	fh, err := s.fs.OpenFile("renamed.txt", 0)
+	s.require.NoError(err, "open renamed")
+	s.assert.Equal("test", fh.(*MockFile).String(), "renamed content")
+	err = s.fs.Rename("testing.txt", "renamed.txt")
+	s.require.True(os.IsNotExist(err), "rename not found")
+}
+
+func (s *MockSuite) TestRemove() {
+	s.fs.Add("testing.txt")
+	s.require.NoError(s.fs.Remove("testing.txt"), "fs remove")
+	_, err := s.fs.Stat("testing.txt")
+	s.require.True(os.IsNotExist(err), "removed file not found")
+	err = s.fs.Remove("testing.txt")
+	s.require.True(os.IsNotExist(err), "remove not found")
+}
@@ -33,3 +33,18 @@ func (fs *NativeFilesystem) Mkdir(path string) error {
 func (fs *NativeFilesystem) MkdirAll(path string) error {
 	return os.MkdirAll(path, dirPerm)
 }
+
+// Chmod calls os.Chmod.
+func (fs *NativeFilesystem) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// Rename calls os.Rename.
+func (fs *NativeFilesystem) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+// Remove calls os.Remove.
+func (fs *NativeFilesystem) Remove(name string) error {
+	return os.Remove(name)
+}
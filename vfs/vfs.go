@@ -28,6 +28,9 @@ type Filesystem interface {
 	Stat(filename string) (os.FileInfo, error)
 	Mkdir(path string) error
 	MkdirAll(path string) error
+	Chmod(name string, mode os.FileMode) error
+	Rename(oldname, newname string) error
+	Remove(name string) error
 }
 
 var fs Filesystem
@@ -92,6 +95,22 @@ func MkdirAll(path string) error {
 	return fs.MkdirAll(path)
 }
 
+// Chmod changes the mode of the named file on current filesystem.
+func Chmod(name string, mode os.FileMode) error {
+	return fs.Chmod(name, mode)
+}
+
+// Rename renames the named file on current filesystem, replacing newname if it
+// already exists.
+func Rename(oldname, newname string) error {
+	return fs.Rename(oldname, newname)
+}
+
+// Remove removes the named file on current filesystem.
+func Remove(name string) error {
+	return fs.Remove(name)
+}
+
 // Open opens the named file as read only.
 func Open(name string) (File, error) {
 	return fs.OpenFile(name, os.O_RDONLY)
@@ -84,6 +84,12 @@ func (s *Suite) TestOpenFileError() {
 	require.Error(err, "open error")
 }
 
+func (s *Suite) TestChmod() {
+	require := require.New(s.T())
+	require.NoError(Chmod("stat.txt", 0600), "chmod error")
+	require.Error(Chmod("stat.err", 0600), "chmod not found error")
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, &Suite{suite.New()})
 }
@@ -134,3 +140,32 @@ func TestCreate(t *testing.T) {
 	_, err = Create(fh.Name())
 	assert.NoError(err)
 }
+
+func TestNativeChmod(t *testing.T) {
+	require := require.New(t)
+	fh, err := ioutil.TempFile("", "vfs_test_chmod")
+	require.NoError(err)
+	defer os.Remove(fh.Name())
+	defer fh.Close()
+	fs := new(NativeFilesystem)
+	require.NoError(fs.Chmod(fh.Name(), 0600), "chmod")
+	fi, err := fs.Stat(fh.Name())
+	require.NoError(err, "fs stat")
+	require.Equal(os.FileMode(0600), fi.Mode().Perm(), "file mode")
+}
+
+func TestNativeRename(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "vfs_test_rename")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	fs := new(NativeFilesystem)
+	fn := filepath.Join(dir, "a.txt")
+	require.NoError(ioutil.WriteFile(fn, []byte("a"), 0600))
+	require.NoError(fs.Rename(fn, fn+".old"), "rename")
+	_, err = fs.Stat(fn)
+	require.True(os.IsNotExist(err), "renamed")
+	require.NoError(fs.Remove(fn+".old"), "remove")
+	err = fs.Remove(fn + ".old")
+	require.True(os.IsNotExist(err), "remove not found")
+}